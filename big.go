@@ -0,0 +1,130 @@
+package elastic
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+)
+
+var bigIntType = reflect.TypeOf((*big.Int)(nil))
+var bigFloatType = reflect.TypeOf((*big.Float)(nil))
+
+func init() {
+	builtinSourceConverters[bigIntType] = convertFromBigInt
+	builtinTargetConverters[bigIntType] = convertToBigInt
+	builtinSourceConverters[bigFloatType] = convertFromBigFloat
+	builtinTargetConverters[bigFloatType] = convertToBigFloat
+}
+
+// convertFromBigInt converts a *big.Int to strings and fixed-width numbers, checking for overflows
+func convertFromBigInt(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	x := source.(*big.Int)
+	switch kind := targetType.Kind(); {
+	case kind == reflect.String:
+		return x.String(), nil
+	case isInt(kind):
+		if !x.IsInt64() {
+			return nil, overflowError(x, targetType)
+		}
+		return x.Int64(), nil
+	case isUint(kind):
+		if !x.IsUint64() {
+			return nil, overflowError(x, targetType)
+		}
+		return x.Uint64(), nil
+	case isFloat(kind):
+		f, _ := new(big.Float).SetInt(x).Float64()
+		if math.IsInf(f, 0) {
+			return nil, overflowError(x, targetType)
+		}
+		return f, nil
+	case targetType == bigFloatType:
+		return new(big.Float).SetInt(x), nil
+	}
+	return nil, ErrNoConversionAvailable
+}
+
+// convertToBigInt builds a *big.Int out of numbers and numeric strings, which may use the engine's separators.
+// Floats are rounded according to the engine's rounding mode, or rejected in strict mode if not exact
+func convertToBigInt(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	switch kind := S.Kind(); {
+	case kind == reflect.String:
+		s, err := ce.normalizeNumber(S.String())
+		if err != nil {
+			return nil, parseError(err, targetType)
+		}
+		x, ok := new(big.Int).SetString(s, integerBase(s))
+		if !ok {
+			return nil, fmt.Errorf("%w: cannot parse %q as %s", ErrParse, S.String(), targetType)
+		}
+		return x, nil
+	case isInt(kind):
+		return big.NewInt(S.Int()), nil
+	case isUint(kind):
+		return new(big.Int).SetUint64(S.Uint()), nil
+	case isFloat(kind):
+		f, err := ce.roundFloat(S.Float(), targetType)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, overflowError(f, targetType)
+		}
+		x, _ := big.NewFloat(f).Int(nil)
+		return x, nil
+	}
+	return nil, ErrNoConversionAvailable
+}
+
+// convertFromBigFloat converts a *big.Float to strings and fixed-width numbers, checking for overflows.
+// Infinite values convert to infinite floats, but finite values too large for float64 overflow
+func convertFromBigFloat(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	x := source.(*big.Float)
+	switch kind := targetType.Kind(); {
+	case kind == reflect.String:
+		return x.Text('g', -1), nil
+	case isInt(kind) || isUint(kind) || targetType == bigIntType:
+		if x.IsInt() {
+			i, _ := x.Int(nil)
+			return i, nil
+		}
+		f, _ := x.Float64()
+		return f, nil // let the float conversion round it
+	case isFloat(kind):
+		f, _ := x.Float64()
+		if math.IsInf(f, 0) && !x.IsInf() {
+			return nil, overflowError(x, targetType)
+		}
+		return f, nil
+	}
+	return nil, ErrNoConversionAvailable
+}
+
+// convertToBigFloat builds a *big.Float out of numbers and numeric strings, which may use the engine's separators
+func convertToBigFloat(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	switch kind := S.Kind(); {
+	case kind == reflect.String:
+		s, err := ce.normalizeNumber(S.String())
+		if err != nil {
+			return nil, parseError(err, targetType)
+		}
+		x, ok := new(big.Float).SetString(s)
+		if !ok {
+			return nil, fmt.Errorf("%w: cannot parse %q as %s", ErrParse, S.String(), targetType)
+		}
+		return x, nil
+	case isInt(kind):
+		return new(big.Float).SetInt64(S.Int()), nil
+	case isUint(kind):
+		return new(big.Float).SetUint64(S.Uint()), nil
+	case isFloat(kind):
+		if math.IsNaN(S.Float()) {
+			return nil, overflowError(S.Float(), targetType)
+		}
+		return big.NewFloat(S.Float()), nil
+	}
+	return nil, ErrNoConversionAvailable
+}
@@ -0,0 +1,122 @@
+package elastic_test
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+func TestBigNumbers(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	var x *big.Int
+	err := elastic.Set(&x, "123456789012345678901234567890")
+	t.Ok(err)
+	t.Equals(0, huge.Cmp(x))
+
+	err = elastic.Set(&x, "0xff")
+	t.Ok(err)
+	t.Equals(int64(255), x.Int64())
+
+	err = elastic.Set(&x, int8(-5))
+	t.Ok(err)
+	t.Equals(int64(-5), x.Int64())
+
+	err = elastic.Set(&x, uint64(math.MaxUint64))
+	t.Ok(err)
+	t.Equals(uint64(math.MaxUint64), x.Uint64())
+
+	err = elastic.Set(&x, 2.7)
+	t.Ok(err)
+	t.Equals(int64(2), x.Int64())
+
+	err = elastic.Set(&x, "12x")
+	t.MustFail(err, "Expected parsing to fail")
+
+	var i int64
+	err = elastic.Set(&i, big.NewInt(42))
+	t.Ok(err)
+	t.Equals(int64(42), i)
+
+	err = elastic.Set(&i, huge)
+	t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected overflow, got %v", err)
+
+	var i8 int8
+	err = elastic.Set(&i8, big.NewInt(300))
+	t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected overflow, got %v", err)
+
+	var s string
+	err = elastic.Set(&s, huge)
+	t.Ok(err)
+	t.Equals("123456789012345678901234567890", s)
+
+	var f *big.Float
+	err = elastic.Set(&f, "1.5")
+	t.Ok(err)
+	t.Equals(0, big.NewFloat(1.5).Cmp(f))
+
+	err = elastic.Set(&f, huge)
+	t.Ok(err)
+	t.Assert(f.IsInt(), "Expected an integer")
+
+	err = elastic.Set(&s, big.NewFloat(0.25))
+	t.Ok(err)
+	t.Equals("0.25", s)
+
+	var f64 float64
+	err = elastic.Set(&f64, big.NewFloat(0.25))
+	t.Ok(err)
+	t.Equals(0.25, f64)
+
+	// values beyond the float range overflow rather than becoming infinite
+	tooBig := new(big.Int).Lsh(big.NewInt(1), 1100)
+	err = elastic.Set(&f64, tooBig)
+	t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected overflow, got %v", err)
+
+	err = elastic.Set(&f64, new(big.Int).Neg(tooBig))
+	t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected overflow, got %v", err)
+
+	err = elastic.Set(&f64, new(big.Float).SetInt(tooBig))
+	t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected overflow, got %v", err)
+
+	var f32 float32
+	err = elastic.Set(&f32, new(big.Int).Lsh(big.NewInt(1), 200))
+	t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected overflow, got %v", err)
+
+	err = elastic.Set(&f64, new(big.Float).SetInf(false))
+	t.Ok(err)
+	t.Assert(math.IsInf(f64, 1), "Expected +Inf, got %v", f64)
+
+	engine := elastic.New()
+	engine.RoundingMode = elastic.Round
+	err = engine.Set(&i, big.NewFloat(2.5))
+	t.Ok(err)
+	t.Equals(int64(3), i)
+
+	err = engine.Set(&x, big.NewFloat(-2.5))
+	t.Ok(err)
+	t.Equals(int64(-3), x.Int64())
+
+	r, err := elastic.Convert(big.NewInt(7), reflect.TypeOf(uint16(0)))
+	t.Ok(err)
+	t.Equals(uint16(7), r)
+
+	// numeric strings are parsed with the engine's separators
+	engine = elastic.NewWithOptions(elastic.WithSeparators(',', '.'))
+	t.Ok(engine.Set(&f, "1.234,5"))
+	t.Equals(0, big.NewFloat(1234.5).Cmp(f))
+	t.Ok(engine.Set(&x, "-1.234.567"))
+	t.Equals(int64(-1234567), x.Int64())
+	err = engine.Set(&f, "1.23,4")
+	t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+	err = engine.Set(&x, "12.34")
+	t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+}
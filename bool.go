@@ -0,0 +1,40 @@
+package elastic
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DefaultTrueStrings are the strings recognized as true by default
+var DefaultTrueStrings = []string{"true", "1", "t", "yes", "y", "on"}
+
+// DefaultFalseStrings are the strings recognized as false by default
+var DefaultFalseStrings = []string{"false", "0", "f", "no", "n", "off"}
+
+// parseBool parses a boolean out of a string, matching the engine's true and false strings case-insensitively
+func (ce *ConverterEngine) parseBool(s string) (bool, error) {
+	for _, token := range ce.TrueStrings {
+		if strings.EqualFold(s, token) {
+			return true, nil
+		}
+	}
+	for _, token := range ce.FalseStrings {
+		if strings.EqualFold(s, token) {
+			return false, nil
+		}
+	}
+	return false, &strconv.NumError{Func: "ParseBool", Num: s, Err: strconv.ErrSyntax}
+}
+
+// formatBool formats a boolean as the first of the engine's true or false strings,
+// or as strconv.FormatBool does if there are none
+func (ce *ConverterEngine) formatBool(b bool) string {
+	tokens := ce.FalseStrings
+	if b {
+		tokens = ce.TrueStrings
+	}
+	if len(tokens) == 0 {
+		return strconv.FormatBool(b)
+	}
+	return tokens[0]
+}
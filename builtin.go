@@ -0,0 +1,21 @@
+package elastic
+
+import (
+	"reflect"
+)
+
+// builtinConverterFunc is a conversion function the engine provides out of the box for well-known types.
+// It receives the engine so that it can honor its options, and the conversion it is part of
+// so that it can convert nested values
+type builtinConverterFunc func(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error)
+
+// builtinSourceConverters contains the default conversion functions for well-known source types
+var builtinSourceConverters = make(map[reflect.Type]builtinConverterFunc)
+
+// builtinTargetConverters contains the default conversion functions for well-known target types
+var builtinTargetConverters = make(map[reflect.Type]builtinConverterFunc)
+
+// hasBuiltinConverter returns true if the engine knows how to deal with the given type out of the box
+func hasBuiltinConverter(t reflect.Type) bool {
+	return builtinSourceConverters[t] != nil || builtinTargetConverters[t] != nil
+}
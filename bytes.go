@@ -0,0 +1,107 @@
+package elastic
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// ErrInvalidUTF8 is returned when converting bytes that are not valid UTF-8 to a string with ValidateUTF8 set
+var ErrInvalidUTF8 = errors.New("Invalid UTF-8")
+
+// ByteStringEncoding defines how byte slices are converted to and from strings
+type ByteStringEncoding int
+
+const (
+	// Raw converts bytes to strings and back as they are. This is the default
+	Raw ByteStringEncoding = iota
+	// Base64 encodes bytes as standard base64 strings
+	Base64
+	// Hex encodes bytes as lowercase hexadecimal strings
+	Hex
+)
+
+// Transcoder converts text from one encoding to another. The decoders and encoders
+// of golang.org/x/text/encoding implement it
+type Transcoder interface {
+	Bytes(b []byte) ([]byte, error)
+}
+
+// Charset defines a character set other than UTF-8 byte slices are converted from and to when converting
+// them to and from strings, such as Latin-1 or Windows-1252. For example, with golang.org/x/text/encoding/charmap:
+//
+//	engine.Charset = &elastic.Charset{
+//		Decoder: charmap.Windows1252.NewDecoder(),
+//		Encoder: charmap.Windows1252.NewEncoder(),
+//	}
+//
+// The decoder and encoder are not used concurrently, so they need not be safe for concurrent use
+type Charset struct {
+	Decoder Transcoder // converts bytes in the character set to UTF-8
+	Encoder Transcoder // converts UTF-8 to bytes in the character set
+
+	mu sync.Mutex
+}
+
+// decode converts bytes in the character set to a string
+func (cs *Charset) decode(b []byte) (string, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	decoded, err := cs.Decoder.Bytes(b)
+	return string(decoded), err
+}
+
+// encode converts a string to bytes in the character set
+func (cs *Charset) encode(s string) ([]byte, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.Encoder.Bytes([]byte(s))
+}
+
+// isBytes returns true if the type is a slice of bytes
+func isBytes(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// isByteArray returns true if the type is a fixed-size array of bytes, such as [16]byte
+func isByteArray(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8
+}
+
+// arrayBytes copies the contents of a byte array into a new byte slice
+func arrayBytes(v reflect.Value) []byte {
+	b := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(b), v)
+	return b
+}
+
+var runesType = reflect.TypeOf([]rune{})
+
+// isRunes returns true if the type is a slice of runes, such as []rune
+func isRunes(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Int32
+}
+
+// encodeBytes encodes a byte slice as a string according to the engine's byte string encoding
+func (ce *ConverterEngine) encodeBytes(b []byte) string {
+	switch ce.ByteStringEncoding {
+	case Base64:
+		return base64.StdEncoding.EncodeToString(b)
+	case Hex:
+		return hex.EncodeToString(b)
+	}
+	return string(b)
+}
+
+// decodeBytes decodes a string into a byte slice according to the engine's byte string encoding
+func (ce *ConverterEngine) decodeBytes(s string) ([]byte, error) {
+	switch ce.ByteStringEncoding {
+	case Base64:
+		return base64.StdEncoding.DecodeString(s)
+	case Hex:
+		return hex.DecodeString(s)
+	}
+	return []byte(s), nil
+}
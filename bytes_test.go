@@ -0,0 +1,185 @@
+package elastic_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+type BytesAlias []byte
+
+func TestByteStringEncoding(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	tests := []struct {
+		encoding elastic.ByteStringEncoding
+		bytes    []byte
+		str      string
+	}{
+		{elastic.Raw, []byte("hello"), "hello"},
+		{elastic.Base64, []byte{0, 1, 2, 253, 254, 255}, "AAEC/f7/"},
+		{elastic.Base64, []byte{}, ""},
+		{elastic.Hex, []byte{0xde, 0xad, 0xbe, 0xef}, "deadbeef"},
+	}
+
+	engine := elastic.New()
+	for _, test := range tests {
+		t.StartSubTest("Encoding %v with %d", test.bytes, test.encoding)
+		engine.ByteStringEncoding = test.encoding
+
+		s, err := engine.Convert(test.bytes, reflect.TypeOf(""))
+		t.Ok(err)
+		t.Equals(test.str, s)
+
+		b, err := engine.Convert(test.str, reflect.TypeOf([]byte{}))
+		t.Ok(err)
+		t.Equals(test.bytes, b)
+
+		// check named types work as well
+		s, err = engine.Convert(BytesAlias(test.bytes), reflect.TypeOf(StringAlias("")))
+		t.Ok(err)
+		t.Equals(StringAlias(test.str), s)
+
+		b, err = engine.Convert(StringAlias(test.str), reflect.TypeOf(BytesAlias{}))
+		t.Ok(err)
+		t.Equals(BytesAlias(test.bytes), b)
+	}
+
+	engine.ByteStringEncoding = elastic.Base64
+	_, err := engine.Convert("not base64!", reflect.TypeOf([]byte{}))
+	t.MustFail(err, "Expected decoding to fail")
+
+	engine.ByteStringEncoding = elastic.Hex
+	b, err := engine.Convert("DEADBEEF", reflect.TypeOf([]byte{}))
+	t.Ok(err)
+	t.Equals([]byte{0xde, 0xad, 0xbe, 0xef}, b)
+
+	_, err = engine.Convert("deadbeefx", reflect.TypeOf([]byte{}))
+	t.MustFail(err, "Expected decoding to fail")
+	_, err = engine.Convert("xyz", reflect.TypeOf([]byte{}))
+	t.MustFail(err, "Expected decoding to fail")
+}
+
+type UUID [16]byte
+
+func TestByteArrays(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	uuidType := reflect.TypeOf(UUID{})
+	id := UUID{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	raw := id[:]
+
+	t.Equals(id, elastic.MustConvert(raw, uuidType))
+	t.Equals(raw, elastic.MustConvert(id, reflect.TypeOf([]byte{})))
+
+	_, err := elastic.Convert(raw[:4], uuidType)
+	t.Assert(errors.Is(err, elastic.ErrLengthMismatch), "Expected ErrLengthMismatch, got %v", err)
+
+	// without an encoding, byte arrays don't convert to strings
+	_, err = elastic.Convert(id, reflect.TypeOf(""))
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+
+	engine := elastic.New()
+	engine.ByteStringEncoding = elastic.Hex
+	t.Equals("123e4567e89b12d3a456426614174000", engine.MustConvert(id, reflect.TypeOf("")))
+	t.Equals(id, engine.MustConvert("123e4567e89b12d3a456426614174000", uuidType))
+	t.Assert(engine.CanConvert(uuidType, reflect.TypeOf("")), "Expected UUID to convert to string")
+
+	_, err = engine.Convert("123e4567", uuidType)
+	t.Assert(errors.Is(err, elastic.ErrLengthMismatch), "Expected ErrLengthMismatch, got %v", err)
+	_, err = engine.Convert("not hex", uuidType)
+	t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+
+	engine.ByteStringEncoding = elastic.Base64
+	t.Equals("Ej5FZ+ibEtOkVkJmFBdAAA==", engine.MustConvert(id, reflect.TypeOf("")))
+	t.Equals(id, engine.MustConvert("Ej5FZ+ibEtOkVkJmFBdAAA==", uuidType))
+}
+
+type RunesAlias []rune
+
+func TestRunes(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	runes := elastic.MustConvert("héllo", reflect.TypeOf([]rune{})).([]rune)
+	t.Equals(5, len(runes))
+	t.Equals('é', runes[1])
+	t.Equals("héllo", elastic.MustConvert(runes, reflect.TypeOf("")))
+	t.Equals(RunesAlias("日本"), elastic.MustConvert(StringAlias("日本"), reflect.TypeOf(RunesAlias{})))
+	t.Equals(StringAlias("日本"), elastic.MustConvert(RunesAlias("日本"), reflect.TypeOf(StringAlias(""))))
+
+	// runes are unaffected by the byte string encoding
+	engine := elastic.New()
+	engine.ByteStringEncoding = elastic.Base64
+	t.Equals([]rune("héllo"), engine.MustConvert("héllo", reflect.TypeOf([]rune{})))
+	t.Equals("héllo", engine.MustConvert([]rune("héllo"), reflect.TypeOf("")))
+}
+
+func TestValidateUTF8(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	invalid := []byte{0xff, 0xfe, 'a'}
+	stringType := reflect.TypeOf("")
+	t.Equals(string(invalid), elastic.MustConvert(invalid, stringType))
+
+	engine := elastic.New()
+	engine.ValidateUTF8 = true
+	_, err := engine.Convert(invalid, stringType)
+	t.MustFailWith(err, elastic.ErrInvalidUTF8)
+	_, err = engine.Convert(BytesAlias(invalid), reflect.TypeOf(StringAlias("")))
+	t.MustFailWith(err, elastic.ErrInvalidUTF8)
+	t.Equals("héllo", engine.MustConvert([]byte("héllo"), stringType))
+
+	// encoded bytes are always valid
+	engine.ByteStringEncoding = elastic.Hex
+	t.Equals("fffe61", engine.MustConvert(invalid, stringType))
+}
+
+// latin1Decoder decodes ISO-8859-1 bytes into UTF-8
+type latin1Decoder struct{}
+
+func (latin1Decoder) Bytes(b []byte) ([]byte, error) {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return []byte(string(runes)), nil
+}
+
+// latin1Encoder encodes UTF-8 into ISO-8859-1 bytes
+type latin1Encoder struct{}
+
+func (latin1Encoder) Bytes(b []byte) ([]byte, error) {
+	var encoded []byte
+	for _, r := range string(b) {
+		if r > 0xff {
+			return nil, errors.New("character not representable in Latin-1")
+		}
+		encoded = append(encoded, byte(r))
+	}
+	return encoded, nil
+}
+
+func TestCharset(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	latin1 := []byte{'c', 'a', 'f', 0xe9}
+	stringType := reflect.TypeOf("")
+	t.Equals(string(latin1), elastic.MustConvert(latin1, stringType))
+
+	engine := elastic.New()
+	engine.Charset = &elastic.Charset{Decoder: latin1Decoder{}, Encoder: latin1Encoder{}}
+	t.Equals("café", engine.MustConvert(latin1, stringType))
+	t.Equals(StringAlias("café"), engine.MustConvert(BytesAlias(latin1), reflect.TypeOf(StringAlias(""))))
+	t.Equals(latin1, engine.MustConvert("café", reflect.TypeOf([]byte{})))
+
+	_, err := engine.Convert("€", reflect.TypeOf([]byte{}))
+	t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+}
@@ -0,0 +1,87 @@
+package elastic
+
+import (
+	"reflect"
+)
+
+// CanConvert reports whether values of the source type can be converted to the target type, without converting
+// any value. Registered converters and types implementing ConverterTo, ConverterFrom and similar interfaces are
+// assumed to handle the types they apply to, since they are not run. Collections are checked element by element.
+// A true result does not guarantee that every value converts: strings may fail to parse, numbers may overflow
+// and arrays may have a different length
+func (ce *ConverterEngine) CanConvert(sourceType, targetType reflect.Type) bool {
+	return ce.canConvert(sourceType, targetType, make(map[typePair]bool))
+}
+
+// canConvert reports whether the source type can be converted to the target type, keeping track
+// of the pairs of types being checked so that recursive types terminate
+func (ce *ConverterEngine) canConvert(sourceType, targetType reflect.Type, checking map[typePair]bool) bool {
+	if sourceType == targetType || sourceType.Kind() == reflect.Interface {
+		return true // interface sources may hold any value, so this is only known when converting
+	}
+	key := typePair{source: sourceType, target: targetType}
+	if checking[key] {
+		return true // recursive types convert as long as the rest of the types do
+	}
+	checking[key] = true
+	defer delete(checking, key)
+
+	plan := ce.plan(sourceType, targetType)
+	if plan.custom() || plan.implementsTarget || plan.textMarshaler || plan.stringer || ce.fallbackConverter != nil {
+		return true
+	}
+	if unsupportedKind(sourceType, targetType) != reflect.Invalid {
+		return false
+	}
+
+	sourceKind, targetKind := sourceType.Kind(), targetType.Kind()
+	switch {
+	case sourceKind == reflect.Ptr && targetKind != reflect.Ptr:
+		return ce.canConvert(sourceType.Elem(), targetType, checking)
+	case targetKind == reflect.Ptr:
+		return ce.canConvert(sourceType, targetType.Elem(), checking)
+	case targetKind == reflect.String && (isScalar(sourceKind) || isBytes(sourceType) || isRunes(sourceType)):
+		return true
+	case sourceKind == reflect.String && isScalar(targetKind):
+		return !ce.DisableStringParsing
+	case sourceKind == reflect.String && (isBytes(targetType) || isRunes(targetType)):
+		return true
+	case ce.ByteStringEncoding != Raw && (sourceKind == reflect.String && isByteArray(targetType) ||
+		isByteArray(sourceType) && targetKind == reflect.String):
+		return true
+	case ce.JoinSlices && isList(sourceKind) && targetKind == reflect.String:
+		return ce.canConvert(sourceType.Elem(), reflect.TypeOf(""), checking) // elements are converted to strings
+	case ce.SplitStrings && sourceKind == reflect.String && isList(targetKind):
+		return ce.canConvert(reflect.TypeOf(""), targetType.Elem(), checking) // pieces are converted to the element type
+	case (isNumber(sourceKind) || sourceKind == reflect.Bool) && (isNumber(targetKind) || targetKind == reflect.Bool):
+		return true
+	case (isNumber(sourceKind) || isComplex(sourceKind)) && isComplex(targetKind):
+		return true
+	case isList(sourceKind) && isList(targetKind):
+		return ce.canConvert(sourceType.Elem(), targetType.Elem(), checking)
+	case sourceKind == reflect.Map && targetKind == reflect.Map:
+		return ce.canConvert(sourceType.Key(), targetType.Key(), checking) &&
+			ce.canConvert(sourceType.Elem(), targetType.Elem(), checking)
+	case sourceKind == reflect.Map && isList(targetKind) && isEntryType(targetType.Elem()):
+		return true
+	case isList(sourceKind) && targetKind == reflect.Map:
+		return ce.canConvert(sourceType.Elem(), entryType, checking)
+	case (sourceKind == reflect.Map || isList(sourceKind)) && targetKind == reflect.Struct && ce.hasMappedFields(targetType),
+		sourceKind == reflect.Struct && targetKind == reflect.Map && targetType.Key().Kind() == reflect.String && ce.hasMappedFields(sourceType),
+		sourceKind == reflect.Struct && isList(targetKind) && ce.hasMappedFields(sourceType),
+		sourceType.ConvertibleTo(targetType),
+		sourceKind == reflect.Struct && targetKind == reflect.Struct:
+		return true // fields are matched when converting, leaving the unmatched ones to their zero value
+	case ce.WrapScalarsIntoSlices && isScalar(sourceKind) && targetKind == reflect.Slice:
+		return ce.canConvert(sourceType, targetType.Elem(), checking)
+	case ce.UnwrapSingleElementSlices && isList(sourceKind) && isScalar(targetKind):
+		return ce.canConvert(sourceType.Elem(), targetType, checking)
+	}
+	return false
+}
+
+// CanConvert reports whether values of the source type can be converted to the target type
+// using the default engine, without converting any value
+func CanConvert(sourceType, targetType reflect.Type) bool {
+	return Default.CanConvert(sourceType, targetType)
+}
@@ -0,0 +1,36 @@
+package elastic
+
+import (
+	"context"
+	"reflect"
+)
+
+// ConvertContext converts the source value to the given target type like Convert,
+// aborting with the context error if the context is done while converting nested maps, slices or structs
+func (ce *ConverterEngine) ConvertContext(ctx context.Context, source interface{}, targetType reflect.Type) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return ce.convert(&conversion{ctx: ctx}, source, targetType)
+}
+
+// SetContext sets the given target pointer to source value like Set,
+// aborting with the context error if the context is done while converting nested maps, slices or structs
+func (ce *ConverterEngine) SetContext(ctx context.Context, target, source interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return ce.set(&conversion{ctx: ctx}, target, source)
+}
+
+// ConvertContext converts the source value to the given target type using the default engine,
+// aborting with the context error if the context is done
+func ConvertContext(ctx context.Context, source interface{}, targetType reflect.Type) (interface{}, error) {
+	return Default.ConvertContext(ctx, source, targetType)
+}
+
+// SetContext sets the given target pointer to source value using the default engine,
+// aborting with the context error if the context is done
+func SetContext(ctx context.Context, target, source interface{}) error {
+	return Default.SetContext(ctx, target, source)
+}
@@ -0,0 +1,48 @@
+package elastic_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+func TestConvertContext(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	ints, err := elastic.ConvertContext(context.Background(), []string{"1", "2"}, reflect.TypeOf([]int{}))
+	t.Ok(err)
+	t.Equals([]int{1, 2}, ints)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = elastic.ConvertContext(ctx, 5, reflect.TypeOf(""))
+	t.Assert(errors.Is(err, context.Canceled), "Expected conversion to be canceled, got %v", err)
+
+	// cancel in the middle of a conversion
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	engine := elastic.New()
+	engine.OnError = elastic.UseZero // cancellation must not be skipped
+	converted := 0
+	engine.AddSourceConverter(reflect.TypeOf(""), func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		converted++
+		if converted == 2 {
+			cancel()
+		}
+		return nil, elastic.ErrNoConversionAvailable
+	})
+	var result []int
+	err = engine.SetContext(ctx, &result, []string{"1", "2", "3", "4"})
+	t.Assert(errors.Is(err, context.Canceled), "Expected conversion to be canceled, got %v", err)
+	t.Equals(2, converted)
+
+	var i int
+	err = elastic.SetContext(context.Background(), &i, "5")
+	t.Ok(err)
+	t.Equals(5, i)
+}
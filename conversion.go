@@ -0,0 +1,113 @@
+package elastic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrorPolicy defines what happens when the conversion of an element within a map, slice or struct fails
+type ErrorPolicy int
+
+const (
+	// Fail aborts the whole conversion. This is the default
+	Fail ErrorPolicy = iota
+	// UseZero uses the zero value of the element's target type and continues
+	UseZero
+)
+
+// ErrMaxDepthExceeded is returned when the source is nested deeper than the engine's MaxDepth
+var ErrMaxDepthExceeded = errors.New("Maximum depth exceeded")
+
+// visit identifies the conversion of a reference (pointer, map or slice) to a target type
+type visit struct {
+	ptr        uintptr
+	sourceType reflect.Type
+	targetType reflect.Type
+}
+
+// conversion holds the state of a conversion as it recurses into nested maps, slices and structs
+type conversion struct {
+	ctx      context.Context // optional context to abort the conversion
+	path     []string        // path to the element being converted
+	visiting map[visit]bool  // references being converted, to detect cycles
+	required *requiredFields // if set, required fields are collected to be checked at the end rather than right away
+}
+
+// enter marks the given reference as being converted, returning ErrCyclicReference
+// if it was already being converted further up, which means the source has a cycle.
+// Returns false if the value is not a reference and therefore can't be part of a cycle
+func (c *conversion) enter(S reflect.Value, targetType reflect.Type) (visit, bool, error) {
+	switch S.Kind() {
+	case reflect.Ptr, reflect.Map:
+		if S.IsNil() {
+			return visit{}, false, nil
+		}
+	case reflect.Slice:
+		if S.Len() == 0 {
+			return visit{}, false, nil
+		}
+	default:
+		return visit{}, false, nil
+	}
+	v := visit{ptr: S.Pointer(), sourceType: S.Type(), targetType: targetType}
+	if c.visiting[v] {
+		return v, false, ErrCyclicReference
+	}
+	if c.visiting == nil {
+		c.visiting = make(map[visit]bool)
+	}
+	c.visiting[v] = true
+	return v, true, nil
+}
+
+// leave marks the given reference as no longer being converted
+func (c *conversion) leave(v visit) {
+	delete(c.visiting, v)
+}
+
+// convertElement converts a nested element, identified by the given path element,
+// handling errors according to the engine's OnError policy
+func (ce *ConverterEngine) convertElement(c *conversion, element string, source interface{}, targetType reflect.Type) (interface{}, error) {
+	return ce.convertElementWith(c, element, targetType, func() (interface{}, error) {
+		return ce.convert(c, source, targetType)
+	})
+}
+
+// convertElementWith converts a nested element using the given function, handling path tracking and
+// errors the same way as convertElement
+func (ce *ConverterEngine) convertElementWith(c *conversion, element string, targetType reflect.Type, convert func() (interface{}, error)) (interface{}, error) {
+	if c.ctx != nil {
+		if err := c.ctx.Err(); err != nil {
+			return nil, err // cancellation is never skipped
+		}
+	}
+	if ce.MaxDepth > 0 && len(c.path) >= ce.MaxDepth {
+		return nil, pathError(fmt.Errorf("%w: more than %d levels", ErrMaxDepthExceeded, ce.MaxDepth), element)
+	}
+	c.path = append(c.path, element)
+	result, err := convert()
+	if err != nil && ce.OnError == UseZero && !aborts(err) {
+		ce.skip(err, joinPath(c.path))
+		result, err = reflect.Zero(targetType).Interface(), nil
+	}
+	c.path = c.path[:len(c.path)-1]
+	if err != nil {
+		return nil, pathError(err, element)
+	}
+	return result, nil
+}
+
+// aborts returns true if the error must abort the whole conversion rather than being skipped by the UseZero policy,
+// such as a cancellation or an input nested too deep
+func aborts(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrMaxDepthExceeded)
+}
+
+// skip reports an error skipped because of the UseZero policy, found at the given path
+func (ce *ConverterEngine) skip(err error, path string) {
+	if ce.OnSkippedError != nil {
+		ce.OnSkippedError(pathError(err, path))
+	}
+}
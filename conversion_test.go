@@ -0,0 +1,137 @@
+package elastic_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+func TestUseZero(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	var skipped []string
+	engine := elastic.New()
+	engine.OnError = elastic.UseZero
+	engine.OnSkippedError = func(err error) {
+		var conversionError *elastic.ConversionError
+		t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+		skipped = append(skipped, conversionError.Path)
+	}
+
+	var ints []int
+	err := engine.Set(&ints, []interface{}{1, "x", 3, Point32{}})
+	t.Ok(err)
+	t.Equals([]int{1, 0, 3, 0}, ints)
+	t.Equals([]string{"[1]", "[3]"}, skipped)
+
+	skipped = nil
+	var list UserList
+	err = engine.Set(&list, map[string]interface{}{
+		"Users": []interface{}{
+			map[string]interface{}{"Name": "john", "Age": "old"},
+			map[string]interface{}{"Name": "paul", "Age": 30},
+		},
+	})
+	t.Ok(err)
+	t.Equals(UserList{Users: []User{{Name: "john"}, {Name: "paul", Age: 30}}}, list)
+	t.Equals([]string{"Users[0].Age"}, skipped)
+
+	skipped = nil
+	var m map[int]int
+	err = engine.Set(&m, map[string]string{"1": "1", "2": "x", "y": "3"})
+	t.Ok(err)
+	t.Equals(map[int]int{1: 1, 2: 0}, m)
+	t.Equals(2, len(skipped))
+
+	// the top level conversion still fails
+	var i int
+	err = engine.Set(&i, "x")
+	t.MustFail(err, "Expected conversion to fail")
+
+	// without a handler errors are silently skipped
+	engine.OnSkippedError = nil
+	err = engine.Set(&ints, []string{"x"})
+	t.Ok(err)
+	t.Equals([]int{0}, ints)
+}
+
+type Node struct {
+	Name string
+	Next *Node
+}
+
+type OtherNode struct {
+	Name string
+	Next *OtherNode
+}
+
+type Tree map[string]Tree
+
+func TestCyclicReference(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	n := &Node{Name: "loop"}
+	n.Next = n
+	var other OtherNode
+	err := elastic.Set(&other, n)
+	t.Assert(errors.Is(err, elastic.ErrCyclicReference), "Expected a cyclic reference error, got %v", err)
+
+	m := map[string]interface{}{}
+	m["self"] = m
+	var tree Tree
+	err = elastic.Set(&tree, m)
+	t.Assert(errors.Is(err, elastic.ErrCyclicReference), "Expected a cyclic reference error, got %v", err)
+
+	// shared references that are not cycles are fine
+	shared := &Node{Name: "shared"}
+	var others []OtherNode
+	err = elastic.Set(&others, []*Node{shared, shared})
+	t.Ok(err)
+	t.Equals([]OtherNode{{Name: "shared"}, {Name: "shared"}}, others)
+
+	err = elastic.Set(&other, &Node{Name: "a", Next: &Node{Name: "b"}})
+	t.Ok(err)
+	t.Equals(OtherNode{Name: "a", Next: &OtherNode{Name: "b"}}, other)
+}
+
+func TestMaxDepth(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	type Node struct {
+		Next  *Node
+		Value int
+	}
+
+	// builds {"Next": {"Next": ... {"Value": 1}}} nested the given number of levels
+	nested := func(levels int) interface{} {
+		m := map[string]interface{}{"Value": 1}
+		for i := 0; i < levels; i++ {
+			m = map[string]interface{}{"Next": m}
+		}
+		return m
+	}
+
+	var node Node
+	t.Ok(elastic.Set(&node, nested(100)))
+
+	engine := elastic.New()
+	engine.MaxDepth = 4
+	t.Ok(engine.Set(&node, nested(3)))
+	t.Equals(1, node.Next.Next.Next.Value)
+
+	err := engine.Set(&node, nested(4))
+	t.Assert(errors.Is(err, elastic.ErrMaxDepthExceeded), "Expected ErrMaxDepthExceeded, got %v", err)
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("Next.Next.Next.Next.Value", conversionError.Path)
+
+	// exceeding the depth is never skipped
+	engine.OnError = elastic.UseZero
+	err = engine.Set(&node, nested(10))
+	t.Assert(errors.Is(err, elastic.ErrMaxDepthExceeded), "Expected ErrMaxDepthExceeded, got %v", err)
+}
@@ -1,10 +1,15 @@
 package elastic
 
 import (
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 // ConverterFunc is called to override default conversions
@@ -15,11 +20,142 @@ type ConverterTo interface {
 	ConvertTo(targetType reflect.Type) (interface{}, error)
 }
 
+// ConverterFrom interface allows you to define how your type should be built from others.
+// It must be implemented with a pointer receiver, so that the method can populate the value
+type ConverterFrom interface {
+	ConvertFrom(source interface{}) error
+}
+
+var converterFromType = reflect.TypeOf((*ConverterFrom)(nil)).Elem()
+
 // ConverterEngine keeps conversion configurations
 type ConverterEngine struct {
+	// TagKey is the struct tag key used to override the map key a struct field is converted to or from.
+	// A tag value of "-" skips the field. Defaults to "elastic", but can be set to "json" to reuse json tags.
+	TagKey string
+
+	// TimeLayout is the layout used to convert time.Time to and from strings. Defaults to RFC3339
+	TimeLayout string
+
+	// ByteStringEncoding defines how byte slices are converted to and from strings. Unless it is Raw,
+	// fixed-size byte arrays such as [16]byte are converted to and from strings as well. Defaults to Raw
+	ByteStringEncoding ByteStringEncoding
+
+	// Charset, if set, is the character set byte slices are decoded from when converted to strings
+	// and encoded to when converted from strings with the Raw encoding. Defaults to nil, which leaves them as UTF-8
+	Charset *Charset
+
+	// ValidateUTF8 makes converting byte slices to strings with the Raw encoding fail with ErrInvalidUTF8
+	// if the bytes are not valid UTF-8. Defaults to false
+	ValidateUTF8 bool
+
+	// FloatFormat is the format verb used to convert floats to strings, as in strconv.FormatFloat. Defaults to 'g'
+	FloatFormat byte
+
+	// FloatPrecision is the precision used to convert floats to strings, as in strconv.FormatFloat.
+	// Defaults to -1, which uses the smallest number of digits that represents the value exactly
+	FloatPrecision int
+
+	// DecimalSeparator and ThousandsSeparator are the separators expected when parsing strings into numbers,
+	// such as ',' and '.' to parse "1.234,56". Thousands separators must group digits by three.
+	// Default to '.' and none, as in strconv
+	DecimalSeparator   rune
+	ThousandsSeparator rune
+
+	// TrueStrings and FalseStrings are the strings recognized as true and false when parsing booleans,
+	// matched case-insensitively. Booleans are formatted as the first of them.
+	// Default to DefaultTrueStrings and DefaultFalseStrings
+	TrueStrings  []string
+	FalseStrings []string
+
+	// OnError defines what happens when the conversion of an element within a map, slice or struct fails.
+	// Defaults to Fail
+	OnError ErrorPolicy
+
+	// MaxDepth, if greater than zero, limits how deep the elements of nested maps, slices and structs can be,
+	// failing with ErrMaxDepthExceeded beyond it. It protects against deeply nested, untrusted inputs
+	// without cycles, which would otherwise recurse as deep as the input goes. Defaults to 0, which means no limit
+	MaxDepth int
+
+	// OnSkippedError, if set, is called with every error skipped because of the UseZero error policy.
+	// Errors are of type *ConversionError, recording the path to the offending element
+	OnSkippedError func(err error)
+
+	// RoundingMode defines how floats are converted to integers. Defaults to Truncate
+	RoundingMode RoundingMode
+
+	// Strict rejects floats with a fractional part converted to integers with ErrPrecisionLoss rather than
+	// rounding them, while negative values converted to unsigned integers and other out of range values always
+	// fail with ErrOverflow. Other conversions, such as integers to floats or float64 to float32, may still
+	// round the value. Defaults to false
+	Strict bool
+
+	// NonFinite defines how NaN and infinite floats are converted to integers. Defaults to NonFiniteError.
+	// Converted to strings, they become "NaN", "+Inf" and "-Inf", which parse back into floats
+	NonFinite NonFinitePolicy
+
+	// UnwrapSingleElementSlices allows converting slices and arrays to scalar types such as numbers, strings or bools
+	// by converting their only element. Empty slices convert to the zero value. Defaults to false
+	UnwrapSingleElementSlices bool
+
+	// WrapScalarsIntoSlices allows converting scalar types such as numbers, strings or bools to slices
+	// by wrapping them into a single element slice. Defaults to false
+	WrapScalarsIntoSlices bool
+
+	// SplitStrings allows converting strings to slices and arrays by splitting them on the ListSeparator,
+	// trimming whitespace around each piece and converting it to the element type, so "1, 2, 3" converts to []int.
+	// Empty strings convert to empty slices. Defaults to false
+	SplitStrings bool
+
+	// JoinSlices allows converting slices and arrays to strings by converting each element to a string
+	// and joining them with the ListSeparator, so []int{1, 2, 3} converts to "1,2,3". Byte and rune slices are not
+	// joined. Since rune is an alias of int32, this includes []int32, which is encoded as UTF-8. Defaults to false
+	JoinSlices bool
+
+	// ListSeparator is the separator strings are split on when SplitStrings is set,
+	// and slice elements are joined with when JoinSlices is set. Defaults to ","
+	ListSeparator string
+
+	// DisableStringParsing prevents strings from being parsed into numbers or bools, so that those conversions
+	// fail with ErrIncompatibleType. Defaults to false
+	DisableStringParsing bool
+
+	// OmitEmpty leaves empty fields out when converting structs to maps, as if all fields had the omitempty
+	// tag option, such as `elastic:",omitempty"`. Nil pointers are empty, but pointers to zero values are not.
+	// Defaults to false
+	OmitEmpty bool
+
+	// CaseInsensitiveFields makes map keys match struct fields case-insensitively when converting maps to structs,
+	// as in encoding/json, if there is no key matching exactly. Defaults to false
+	CaseInsensitiveFields bool
+
+	// ErrorOnUnknownFields makes converting maps to structs fail with ErrUnknownFields if the map has keys that
+	// don't match any struct field, such as misspelled keys in configuration. Defaults to false, ignoring them
+	ErrorOnUnknownFields bool
+
+	// ErrorOnMissingFields makes converting maps to structs fail with ErrMissingFields if the map has no key
+	// for some struct fields, as if all fields had the required tag option, such as `elastic:",required"`.
+	// Defaults to false, leaving those fields untouched
+	ErrorOnMissingFields bool
+
+	// SortMapEntries sorts map entries by key when converting maps to slices of entries, so that the result
+	// is deterministic. Strings and numbers are sorted in their natural order. Defaults to false
+	SortMapEntries bool
+
+	// Trace, if set, is called at each decision point of a conversion with an event describing the conversion
+	// being tried, such as "source converter", "Stringer", "parse string", "slice", "map" or "fallback converter",
+	// along with the source and target type at that point. Useful to diagnose which branch handles a conversion
+	Trace func(event string, source interface{}, targetType reflect.Type)
+
 	sourceConverters    map[reflect.Type][]ConverterFunc
 	targetConverters    map[reflect.Type][]ConverterFunc
-	interfaceConverters map[reflect.Type][]ConverterFunc
+	interfaceConverters []interfaceConverter // in registration order
+	kindConverters      map[reflect.Kind][]ConverterFunc
+	conversions         map[[2]reflect.Type]func(source interface{}) (interface{}, error)
+	fieldConverters     map[structField]ConverterFunc
+	fieldNameMapper     func(goName string) string
+	fallbackConverter   ConverterFunc
+	plans               *sync.Map // cache of conversion plans, keyed by typePair
 }
 
 // Default is a default conversion engine
@@ -28,26 +164,59 @@ var Default = New()
 // ErrExpectedPointer is returned when the function expects a pointer parameter
 var ErrExpectedPointer = errors.New("Expected pointer")
 
-// ErrIncompatibleType is returned when it is impossible to convert a type to another
+// ErrNilPointer is returned when the function expects a non-nil pointer parameter
+var ErrNilPointer = errors.New("Nil pointer")
+
+// ErrNotSettable is returned when the target to set can't be modified
+var ErrNotSettable = errors.New("Target is not settable")
+
+// ErrIncompatibleType is returned when it is impossible to convert a type to another.
+// Conversions return it wrapped in a *ConversionError recording the value that failed to convert
 var ErrIncompatibleType = errors.New("Incompatible types")
 
+// ErrLengthMismatch is returned when converting to a fixed-size array from a source of a different length
+var ErrLengthMismatch = errors.New("Length mismatch")
+
+// ErrCyclicReference is returned when the source contains a cycle, such as a map containing itself
+var ErrCyclicReference = errors.New("Cyclic reference")
+
+// ErrDuplicateKey is returned when several keys of a source map convert to the same key of the target map
+var ErrDuplicateKey = errors.New("Duplicate key")
+
 // ErrNoConversionAvailable is returned by any ConverterFunc when it does not know how to convert the passed values
 var ErrNoConversionAvailable = errors.New("No conversion available")
 
+// interfaceConverter is a conversion function registered for types that match an interface
+type interfaceConverter struct {
+	interfaceType reflect.Type
+	f             ConverterFunc
+}
+
 // New instantiates a new Converter Engine
 func New() *ConverterEngine {
 	return &ConverterEngine{
-		sourceConverters:    make(map[reflect.Type][]ConverterFunc),
-		targetConverters:    make(map[reflect.Type][]ConverterFunc),
-		interfaceConverters: make(map[reflect.Type][]ConverterFunc),
+		TagKey:           DefaultTagKey,
+		TimeLayout:       DefaultTimeLayout,
+		FloatFormat:      'g',
+		FloatPrecision:   -1,
+		DecimalSeparator: '.',
+		ListSeparator:    DefaultListSeparator,
+		TrueStrings:      append([]string(nil), DefaultTrueStrings...),
+		FalseStrings:     append([]string(nil), DefaultFalseStrings...),
+		sourceConverters: make(map[reflect.Type][]ConverterFunc),
+		targetConverters: make(map[reflect.Type][]ConverterFunc),
+		conversions:      make(map[[2]reflect.Type]func(source interface{}) (interface{}, error)),
+		plans:            newPlanCache(),
 	}
 }
 
 // AddSourceConverter adds a source conversion function to the engine that knows how to convert the source type to some targets
+// The function is looked up by the exact dynamic type of the source value, even when it is boxed in an interface
 func (ce *ConverterEngine) AddSourceConverter(sourceType reflect.Type, f ConverterFunc) {
 	cf := ce.sourceConverters[sourceType]
 	cf = append(cf, f)
 	ce.sourceConverters[sourceType] = cf
+	ce.invalidatePlans()
 }
 
 // AddTargetConverter adds a target conversion function to the engine that knows how to convert the target type from some sources
@@ -55,75 +224,556 @@ func (ce *ConverterEngine) AddTargetConverter(targetType reflect.Type, f Convert
 	cf := ce.targetConverters[targetType]
 	cf = append(cf, f)
 	ce.targetConverters[targetType] = cf
+	ce.invalidatePlans()
 }
 
-// AddInterfaceConverter adds a converion function for types that match the given interface (experimental)
+// AddInterfaceConverter adds a converion function for types that match the given interface (experimental).
+// When a type matches several registered interfaces, their conversion functions are tried in registration order
 func (ce *ConverterEngine) AddInterfaceConverter(interfaceType reflect.Type, f ConverterFunc) {
 	if interfaceType.Kind() != reflect.Interface {
 		panic("type must be an interface")
 	}
-	cf := ce.interfaceConverters[interfaceType]
-	cf = append(cf, f)
-	ce.interfaceConverters[interfaceType] = cf
+	ce.interfaceConverters = append(ce.interfaceConverters, interfaceConverter{interfaceType: interfaceType, f: f})
+	ce.invalidatePlans()
+}
+
+// AddKindConverter adds a conversion function to the engine for all source types of the given kind, such as all
+// named int types. It is consulted after the converters registered for the exact source or target type
+// and for interfaces the source implements, and before the built-in conversions
+func (ce *ConverterEngine) AddKindConverter(kind reflect.Kind, f ConverterFunc) {
+	if ce.kindConverters == nil {
+		ce.kindConverters = make(map[reflect.Kind][]ConverterFunc)
+	}
+	ce.kindConverters[kind] = append(ce.kindConverters[kind], f)
+	ce.invalidatePlans()
+}
+
+// AddConversion adds a conversion function to the engine that converts the exact given source type to the exact given target type.
+// It replaces any conversion function previously registered for the same pair of types and takes precedence over all other converters
+func (ce *ConverterEngine) AddConversion(sourceType, targetType reflect.Type, f func(source interface{}) (interface{}, error)) {
+	ce.conversions[[2]reflect.Type{sourceType, targetType}] = f
+	ce.invalidatePlans()
+}
+
+// AddAdapter registers a function that wraps values of the given source type into a value implementing the given
+// interface, which the source type does not implement itself, so that they can be converted to the interface.
+// It is registered as the conversion for the pair of types and can be removed with RemoveConversion
+func (ce *ConverterEngine) AddAdapter(sourceType, interfaceType reflect.Type, adapt func(source interface{}) (interface{}, error)) {
+	if interfaceType.Kind() != reflect.Interface {
+		panic("type must be an interface")
+	}
+	if sourceType.Implements(interfaceType) {
+		panic(fmt.Sprintf("%s already implements %s", sourceType, interfaceType))
+	}
+	ce.AddConversion(sourceType, interfaceType, func(source interface{}) (interface{}, error) {
+		adapted, err := adapt(source)
+		if err != nil {
+			return nil, err
+		}
+		if adapted == nil || !reflect.TypeOf(adapted).Implements(interfaceType) {
+			return nil, fmt.Errorf("%w: adapter for %s returned %T, which does not implement %s", ErrIncompatibleType, sourceType, adapted, interfaceType)
+		}
+		return adapted, nil
+	})
+}
+
+// AddBinaryCodec registers the functions to serialize values of the given type to byte slices and back,
+// so that converting the type to a byte slice calls encode and converting a byte slice to the type calls decode.
+// They are registered as source and target converters of the type
+func (ce *ConverterEngine) AddBinaryCodec(t reflect.Type, encode func(interface{}) ([]byte, error), decode func([]byte) (interface{}, error)) {
+	ce.AddSourceConverter(t, func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		if !isBytes(targetType) {
+			return nil, ErrNoConversionAvailable
+		}
+		return encode(source)
+	})
+	ce.AddTargetConverter(t, func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		S := reflect.ValueOf(source)
+		if !isBytes(S.Type()) {
+			return nil, ErrNoConversionAvailable
+		}
+		return decode(S.Bytes())
+	})
+}
+
+// SetFallbackConverter sets a conversion function that is invoked as a last resort when no other conversion applies.
+// If it returns ErrNoConversionAvailable, the conversion fails with ErrIncompatibleType. Pass nil to remove it
+func (ce *ConverterEngine) SetFallbackConverter(f ConverterFunc) {
+	ce.fallbackConverter = f
+}
+
+// RemoveSourceConverter removes all source conversion functions registered for the given type
+func (ce *ConverterEngine) RemoveSourceConverter(sourceType reflect.Type) {
+	delete(ce.sourceConverters, sourceType)
+	ce.invalidatePlans()
+}
+
+// RemoveTargetConverter removes all target conversion functions registered for the given type
+func (ce *ConverterEngine) RemoveTargetConverter(targetType reflect.Type) {
+	delete(ce.targetConverters, targetType)
+	ce.invalidatePlans()
+}
+
+// RemoveInterfaceConverter removes all conversion functions registered for the given interface
+func (ce *ConverterEngine) RemoveInterfaceConverter(interfaceType reflect.Type) {
+	var converters []interfaceConverter
+	for _, converter := range ce.interfaceConverters {
+		if converter.interfaceType != interfaceType {
+			converters = append(converters, converter)
+		}
+	}
+	ce.interfaceConverters = converters
+	ce.invalidatePlans()
+}
+
+// RemoveKindConverter removes all conversion functions registered for the given kind
+func (ce *ConverterEngine) RemoveKindConverter(kind reflect.Kind) {
+	delete(ce.kindConverters, kind)
+	ce.invalidatePlans()
+}
+
+// RemoveConversion removes the conversion function registered for the given pair of types
+func (ce *ConverterEngine) RemoveConversion(sourceType, targetType reflect.Type) {
+	delete(ce.conversions, [2]reflect.Type{sourceType, targetType})
+	ce.invalidatePlans()
+}
+
+// Reset removes all conversion functions registered in the engine
+func (ce *ConverterEngine) Reset() {
+	ce.sourceConverters = make(map[reflect.Type][]ConverterFunc)
+	ce.targetConverters = make(map[reflect.Type][]ConverterFunc)
+	ce.interfaceConverters = nil
+	ce.kindConverters = nil
+	ce.conversions = make(map[[2]reflect.Type]func(source interface{}) (interface{}, error))
+	ce.fieldConverters = nil
+	ce.fallbackConverter = nil
+	ce.invalidatePlans()
+}
+
+// copyConverters returns a deep copy of the given converter map
+func copyConverters(m map[reflect.Type][]ConverterFunc) map[reflect.Type][]ConverterFunc {
+	c := make(map[reflect.Type][]ConverterFunc, len(m))
+	for t, converters := range m {
+		c[t] = append([]ConverterFunc(nil), converters...)
+	}
+	return c
+}
+
+// Clone returns a copy of the engine with the same options and conversion functions,
+// which can be customized without affecting the original
+func (ce *ConverterEngine) Clone() *ConverterEngine {
+	clone := *ce
+	clone.sourceConverters = copyConverters(ce.sourceConverters)
+	clone.targetConverters = copyConverters(ce.targetConverters)
+	clone.interfaceConverters = append([]interfaceConverter(nil), ce.interfaceConverters...)
+	clone.kindConverters = make(map[reflect.Kind][]ConverterFunc, len(ce.kindConverters))
+	for kind, converters := range ce.kindConverters {
+		clone.kindConverters[kind] = append([]ConverterFunc(nil), converters...)
+	}
+	clone.conversions = make(map[[2]reflect.Type]func(source interface{}) (interface{}, error), len(ce.conversions))
+	for pair, f := range ce.conversions {
+		clone.conversions[pair] = f
+	}
+	clone.fieldConverters = make(map[structField]ConverterFunc, len(ce.fieldConverters))
+	for field, f := range ce.fieldConverters {
+		clone.fieldConverters[field] = f
+	}
+	clone.plans = newPlanCache()
+	clone.TrueStrings = append([]string(nil), ce.TrueStrings...)
+	clone.FalseStrings = append([]string(nil), ce.FalseStrings...)
+	return &clone
 }
 
 // convertMap attempts to convert the source map to another type of map
-func (ce *ConverterEngine) convertMap(source interface{}, targetType reflect.Type) (interface{}, error) {
-	S := reflect.ValueOf(source)
+func (ce *ConverterEngine) convertMap(c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
 	T := reflect.MakeMap(targetType)
+	if err := ce.fillMap(c, reflect.ValueOf(source), T); err != nil {
+		return nil, err
+	}
+	return T.Interface(), nil
+}
 
-	targetElementType := targetType.Elem()
-	keyType := targetType.Key()
+// fillMap converts the entries of the source map and sets them in the given target map,
+// overwriting the entries that already exist. Source keys that convert to the same key fail with ErrDuplicateKey
+func (ce *ConverterEngine) fillMap(c *conversion, S, T reflect.Value) error {
+	targetElementType := T.Type().Elem()
+	keyType := T.Type().Key()
+	var converted map[interface{}]reflect.Value // source keys by converted key, to detect collisions
+	if S.Type().Key() != keyType {
+		converted = make(map[interface{}]reflect.Value, S.Len())
+	}
 
 	for i := S.MapRange(); i.Next(); {
-		value, err := ce.Convert(i.Value().Interface(), targetElementType)
+		element := fmt.Sprint(i.Key())
+		value, err := ce.convertElement(c, element, i.Value().Interface(), targetElementType)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		key, err := ce.Convert(i.Key().Interface(), keyType)
+		key, err := ce.convert(c, i.Key().Interface(), keyType)
 		if err != nil {
-			return nil, err
+			err = keyError(err, i.Key(), keyType)
+		} else if previous, found := converted[key]; found {
+			err = fmt.Errorf("%w: keys %#v and %#v both convert to %#v", ErrDuplicateKey, previous.Interface(), i.Key().Interface(), key)
+		}
+		if err != nil {
+			if ce.OnError == UseZero {
+				ce.skip(err, joinPath(append(c.path, element)))
+				continue // entries with unconvertible or colliding keys are left out
+			}
+			return pathError(err, element)
+		}
+		if converted != nil {
+			converted[key] = i.Key()
+		}
+		T.SetMapIndex(valueOf(key, keyType), valueOf(value, targetElementType))
+	}
+	return nil
+}
+
+// convertSlice attempts to convert a slice or array to another type of slice or array.
+// Fixed-size array targets require the source to have the same length
+func (ce *ConverterEngine) convertSlice(c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	var T reflect.Value
+	if targetType.Kind() == reflect.Array {
+		if S.Len() != targetType.Len() {
+			return nil, fmt.Errorf("%w: cannot convert %d elements into %s", ErrLengthMismatch, S.Len(), targetType)
 		}
-		T.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(value))
+		T = reflect.New(targetType).Elem()
+	} else {
+		T = reflect.MakeSlice(targetType, S.Len(), S.Len())
+	}
+	if err := ce.fillSlice(c, S, T); err != nil {
+		return nil, err
 	}
 	return T.Interface(), nil
 }
 
-// convertSlice attempts to convert a slice to another type of slice
-func (ce *ConverterEngine) convertSlice(source interface{}, targetType reflect.Type) (interface{}, error) {
+// fillSlice converts the elements of the source slice or array and sets them in the given target slice or array,
+// which must have the same length
+func (ce *ConverterEngine) fillSlice(c *conversion, S, T reflect.Value) error {
+	targetElementType := T.Type().Elem()
+	for i := 0; i < S.Len(); i++ {
+		item, err := ce.convertElement(c, indexElement(i), S.Index(i).Interface(), targetElementType)
+		if err != nil {
+			return err
+		}
+		T.Index(i).Set(valueOf(item, targetElementType))
+	}
+	return nil
+}
+
+// entryType is the type map entries are converted through, holding a key and a value
+var entryType = reflect.TypeOf([2]interface{}{})
+
+// isEntryType returns true if the type can hold a map entry, that is, a struct with the key and value
+// as its first two fields, or an array or slice holding the key and the value
+func isEntryType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct || isList(t.Kind())
+}
+
+// convertMapToSlice attempts to convert a map into a slice or array of entries, each holding a key and a value.
+// Entries are converted positionally to the element type, such as a struct with two fields or a 2-element array.
+// The order of the entries is unspecified, unless the engine's SortMapEntries option is set
+func (ce *ConverterEngine) convertMapToSlice(c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	keys := S.MapKeys()
+	if ce.SortMapEntries {
+		sortKeys(keys)
+	}
+	entries := make([]interface{}, len(keys))
+	for i, key := range keys {
+		entries[i] = []interface{}{key.Interface(), S.MapIndex(key).Interface()}
+	}
+	return ce.convertSlice(c, entries, targetType)
+}
+
+// sortKeys sorts map keys in their natural order when they are strings or numbers,
+// or by their string representation otherwise
+func sortKeys(keys []reflect.Value) {
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		switch kind := a.Kind(); {
+		case kind == reflect.String:
+			return a.String() < b.String()
+		case isInt(kind):
+			return a.Int() < b.Int()
+		case isUint(kind):
+			return a.Uint() < b.Uint()
+		case isFloat(kind):
+			return a.Float() < b.Float()
+		}
+		return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+	})
+}
+
+// convertSliceToMap attempts to build a map out of a slice or array of entries, each holding a key and a value,
+// such as structs with two fields or 2-element arrays
+func (ce *ConverterEngine) convertSliceToMap(c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
 	S := reflect.ValueOf(source)
-	T := reflect.MakeSlice(targetType, 0, S.Len())
+	T := reflect.MakeMapWithSize(targetType, S.Len())
+	keyType := targetType.Key()
 	targetElementType := targetType.Elem()
 
 	for i := 0; i < S.Len(); i++ {
-		item, err := ce.Convert(S.Index(i).Interface(), targetElementType)
+		element := indexElement(i)
+		var key interface{}
+		entry, err := ce.convert(c, S.Index(i).Interface(), entryType)
+		if err == nil {
+			key, err = ce.convert(c, entry.([2]interface{})[0], keyType)
+		}
+		if err != nil {
+			if ce.OnError == UseZero {
+				ce.skip(err, joinPath(append(c.path, element)))
+				continue // entries that can't be converted are left out
+			}
+			return nil, pathError(err, element)
+		}
+		value, err := ce.convertElement(c, element, entry.([2]interface{})[1], targetElementType)
 		if err != nil {
 			return nil, err
 		}
-		T = reflect.Append(T, reflect.ValueOf(item))
+		T.SetMapIndex(valueOf(key, keyType), valueOf(value, targetElementType))
 	}
 	return T.Interface(), nil
 }
 
+// ConvertSlice converts a slice or array to the given target type, which must be a slice or an array.
+// It skips the type dispatch of Convert unless converters or interfaces handle the source or target type,
+// returning the same results
+func (ce *ConverterEngine) ConvertSlice(source interface{}, targetType reflect.Type) (interface{}, error) {
+	if source == nil || !isList(reflect.TypeOf(source).Kind()) || !isList(targetType.Kind()) {
+		return nil, sourceError(ErrIncompatibleType, source, targetType)
+	}
+	if ce.plan(reflect.TypeOf(source), targetType).custom() {
+		return ce.convert(new(conversion), source, targetType)
+	}
+	return ce.convertSlice(new(conversion), source, targetType)
+}
+
+// ConvertMap converts a map to the given target type, which must be a map.
+// It skips the type dispatch of Convert unless converters or interfaces handle the source or target type,
+// returning the same results
+func (ce *ConverterEngine) ConvertMap(source interface{}, targetType reflect.Type) (interface{}, error) {
+	if source == nil || reflect.TypeOf(source).Kind() != reflect.Map || targetType.Kind() != reflect.Map {
+		return nil, sourceError(ErrIncompatibleType, source, targetType)
+	}
+	if ce.plan(reflect.TypeOf(source), targetType).custom() {
+		return ce.convert(new(conversion), source, targetType)
+	}
+	return ce.convertMap(new(conversion), source, targetType)
+}
+
+// ConvertAll converts every source value to the given target type, attempting all of them even if some fail.
+// Returns the converted values in the same order as the sources and, if any conversion failed, a slice of
+// the same length holding the error of each failed index as a *ConversionError, or nil for those that succeeded.
+// The results of failed indexes are nil
+func (ce *ConverterEngine) ConvertAll(sources []interface{}, targetType reflect.Type) ([]interface{}, []error) {
+	results := make([]interface{}, len(sources))
+	var errs []error
+	for i, source := range sources {
+		result, err := ce.Convert(source, targetType)
+		if err != nil {
+			if errs == nil {
+				errs = make([]error, len(sources))
+			}
+			errs[i] = pathError(err, indexElement(i))
+			continue
+		}
+		results[i] = result
+	}
+	return results, errs
+}
+
+// ConvertStream converts the elements of a slice or array one at a time to the given element type, passing each
+// converted element along with its index to yield, so that large slices can be processed without holding
+// the whole converted slice in memory. It stops and returns the error of yield as soon as it returns one.
+// Elements that fail to convert are handled according to the engine's OnError policy
+func (ce *ConverterEngine) ConvertStream(source interface{}, targetElemType reflect.Type, yield func(i int, v interface{}) error) error {
+	if source == nil || !isList(reflect.TypeOf(source).Kind()) {
+		return sourceError(ErrIncompatibleType, source, reflect.SliceOf(targetElemType))
+	}
+	S := reflect.ValueOf(source)
+	c := new(conversion)
+	for i := 0; i < S.Len(); i++ {
+		value, err := ce.convertElement(c, indexElement(i), S.Index(i).Interface(), targetElemType)
+		if err != nil {
+			return err
+		}
+		if err := yield(i, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unsupportedKind returns the kind of the given types that can't be converted, if any, or reflect.Invalid otherwise
+func unsupportedKind(types ...reflect.Type) reflect.Kind {
+	for _, t := range types {
+		switch kind := t.Kind(); kind {
+		case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+			return kind
+		}
+	}
+	return reflect.Invalid
+}
+
+// isList returns true if the kind is a slice or an array
+func isList(kind reflect.Kind) bool {
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+// isScalar returns true if the kind holds a single value, that is, a bool, a number or a string
+func isScalar(kind reflect.Kind) bool {
+	return kind == reflect.Bool || kind == reflect.String || isNumber(kind) || isComplex(kind)
+}
+
+// unwrapSlice converts a slice or array to a scalar type by converting its only element.
+// Empty slices convert to the zero value of the target type
+func (ce *ConverterEngine) unwrapSlice(c *conversion, S reflect.Value, targetType reflect.Type) (interface{}, error) {
+	switch S.Len() {
+	case 0:
+		return reflect.Zero(targetType).Interface(), nil
+	case 1:
+		return ce.convertElement(c, indexElement(0), S.Index(0).Interface(), targetType)
+	}
+	return nil, fmt.Errorf("%w: cannot convert %d elements into %s", ErrLengthMismatch, S.Len(), targetType)
+}
+
+// DefaultListSeparator is the separator strings are split on and slice elements are joined with by default
+const DefaultListSeparator = ","
+
+// listSeparator returns the engine's list separator, or the default one if not set
+func (ce *ConverterEngine) listSeparator() string {
+	if ce.ListSeparator == "" {
+		return DefaultListSeparator
+	}
+	return ce.ListSeparator
+}
+
+// splitString converts a string to a slice or array type by splitting it on the engine's list separator
+// and converting each piece, with whitespace trimmed, to the element type. Empty strings have no elements
+func (ce *ConverterEngine) splitString(c *conversion, s string, targetType reflect.Type) (interface{}, error) {
+	pieces := []string{}
+	if strings.TrimSpace(s) != "" {
+		pieces = strings.Split(s, ce.listSeparator())
+		for i, piece := range pieces {
+			pieces[i] = strings.TrimSpace(piece)
+		}
+	}
+	return ce.convertSlice(c, pieces, targetType)
+}
+
+// joinSlice converts a slice or array to a string type by converting each element to a string
+// and joining them with the engine's list separator
+func (ce *ConverterEngine) joinSlice(c *conversion, S reflect.Value, targetType reflect.Type) (interface{}, error) {
+	pieces := make([]string, S.Len())
+	stringType := reflect.TypeOf("")
+	for i := range pieces {
+		piece, err := ce.convertElement(c, indexElement(i), S.Index(i).Interface(), stringType)
+		if err != nil {
+			return nil, err
+		}
+		pieces[i] = piece.(string)
+	}
+	return kind2Exact(strings.Join(pieces, ce.listSeparator()), targetType), nil
+}
+
+// wrapScalar converts a scalar value to a slice type by wrapping it into a single element slice
+func (ce *ConverterEngine) wrapScalar(c *conversion, S reflect.Value, targetType reflect.Type) (interface{}, error) {
+	wrapped := reflect.MakeSlice(reflect.SliceOf(S.Type()), 1, 1)
+	wrapped.Index(0).Set(S)
+	return ce.convertSlice(c, wrapped.Interface(), targetType)
+}
+
+// valueOf returns a reflect.Value holding v, or the zero value of the given type if v is nil
+func valueOf(v interface{}, t reflect.Type) reflect.Value {
+	if v == nil {
+		return reflect.Zero(t)
+	}
+	return reflect.ValueOf(v)
+}
+
 // kind2Exact converts a type of the same kind
 func kind2Exact(source interface{}, targetType reflect.Type) interface{} {
 	return reflect.ValueOf(source).Convert(targetType).Interface()
 }
 
+// trace reports a conversion event if tracing is enabled
+func (ce *ConverterEngine) trace(event string, source interface{}, targetType reflect.Type) {
+	if ce.Trace != nil {
+		ce.Trace(event, source, targetType)
+	}
+}
+
 // Convert attempts to convert the source value to the given target type
 // if it does not fail, the returned value is guaranteed to be of the target type
 func (ce *ConverterEngine) Convert(source interface{}, targetType reflect.Type) (interface{}, error) {
+	return ce.convert(new(conversion), source, targetType)
+}
+
+// ConvertLike attempts to convert the source value to the type of the given sample value,
+// such as ConvertLike("5", 0) to get an int. The sample must not be nil
+func (ce *ConverterEngine) ConvertLike(source, sample interface{}) (interface{}, error) {
+	if sample == nil {
+		return nil, fmt.Errorf("%w: cannot infer the target type of a nil sample", ErrIncompatibleType)
+	}
+	return ce.Convert(source, reflect.TypeOf(sample))
+}
+
+// convert converts the source value to the given target type as part of the given conversion
+func (ce *ConverterEngine) convert(c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	if source == nil {
+		ce.trace("nil", source, targetType)
+		return reflect.Zero(targetType).Interface(), nil // nil converts to the zero value of any type
+	}
+
 	sourceType := reflect.TypeOf(source)
 	if sourceType == targetType {
+		ce.trace("same type", source, targetType)
 		return source, nil // no conversion necessary
 	}
 
+	S := reflect.ValueOf(source)
+	if sourceType.Kind() == reflect.Ptr && S.IsNil() {
+		return ce.convert(c, nil, targetType) // a nil pointer is treated as a nil source
+	}
+
+	// keep track of the references being converted to detect cycles
+	v, entered, err := c.enter(S, targetType)
+	if err != nil {
+		return nil, err
+	}
+	if entered {
+		defer c.leave(v)
+	}
+
+	plan := ce.plan(sourceType, targetType)
+
+	// check if there is a conversion function for this exact pair of types
+	if plan.conversion != nil {
+		ce.trace("pair conversion", source, targetType)
+		result, err := plan.conversion(source)
+		if err == nil {
+			return ce.convert(c, result, targetType)
+		}
+		if err != ErrNoConversionAvailable {
+			return nil, err
+		}
+	}
+
+	// empty interface targets, such as the elements of []interface{} or map[string]interface{}, hold the source as is
+	if plan.boxesSource {
+		ce.trace("interface target", source, targetType)
+		return source, nil
+	}
+
 	// check if there are any custom source converters
-	converters := ce.sourceConverters[reflect.TypeOf(source)]
-	for _, converter := range converters {
+	for _, converter := range plan.sourceConverters {
+		ce.trace("source converter", source, targetType)
 		result, err := converter(source, targetType)
 		if err == nil {
-			return ce.Convert(result, targetType)
+			return ce.convert(c, result, targetType)
 		}
 		if err != ErrNoConversionAvailable {
 			return nil, err
@@ -131,11 +781,24 @@ func (ce *ConverterEngine) Convert(source interface{}, targetType reflect.Type)
 	}
 
 	// check if the source type implements ConverterTo
-	converter, ok := source.(ConverterTo)
-	if ok {
-		result, err := converter.ConvertTo(targetType)
+	if plan.converterTo {
+		ce.trace("ConverterTo", source, targetType)
+		result, err := source.(ConverterTo).ConvertTo(targetType)
+		if err == nil {
+			return ce.convert(c, result, targetType)
+		}
+		if err != ErrNoConversionAvailable {
+			return nil, err
+		}
+	}
+
+	// check if the target type implements ConverterFrom
+	if plan.converterFrom {
+		ce.trace("ConverterFrom", source, targetType)
+		T := reflect.New(targetType)
+		err := T.Interface().(ConverterFrom).ConvertFrom(source)
 		if err == nil {
-			return ce.Convert(result, targetType)
+			return T.Elem().Interface(), nil
 		}
 		if err != ErrNoConversionAvailable {
 			return nil, err
@@ -143,11 +806,11 @@ func (ce *ConverterEngine) Convert(source interface{}, targetType reflect.Type)
 	}
 
 	// check if there are any custom target converters
-	converters = ce.targetConverters[targetType]
-	for _, converter := range converters {
+	for _, converter := range plan.targetConverters {
+		ce.trace("target converter", source, targetType)
 		result, err := converter(source, targetType)
 		if err == nil {
-			return ce.Convert(result, targetType)
+			return ce.convert(c, result, targetType)
 		}
 		if err != ErrNoConversionAvailable {
 			return nil, err
@@ -155,116 +818,511 @@ func (ce *ConverterEngine) Convert(source interface{}, targetType reflect.Type)
 	}
 
 	// check for interface-based converter (experimental)
-	for itype, converters := range ce.interfaceConverters {
-		for _, converter := range converters {
-			if sourceType.Implements(itype) {
-				result, err := converter(source, targetType)
-				if err == nil {
-					return ce.Convert(result, targetType)
-				}
-				if err != ErrNoConversionAvailable {
-					return nil, err
-				}
-			}
+	for _, converter := range plan.interfaceConverters {
+		ce.trace("interface converter", source, targetType)
+		result, err := converter(source, targetType)
+		if err == nil {
+			return ce.convert(c, result, targetType)
+		}
+		if err != ErrNoConversionAvailable {
+			return nil, err
 		}
 	}
 
-	S := reflect.ValueOf(source)
+	// check if there are any converters for the kind of the source
+	for _, converter := range plan.kindConverters {
+		ce.trace("kind converter", source, targetType)
+		result, err := converter(source, targetType)
+		if err == nil {
+			return ce.convert(c, result, targetType)
+		}
+		if err != ErrNoConversionAvailable {
+			return nil, err
+		}
+	}
+
+	// interface targets hold any source implementing them as is
+	if plan.implementsTarget {
+		ce.trace("interface target", source, targetType)
+		return source, nil
+	}
+
+	// channels, funcs and unsafe pointers can't be converted, other than by the converters above
+	if kind := unsupportedKind(sourceType, targetType); kind != reflect.Invalid {
+		ce.trace("unsupported", source, targetType)
+		return nil, sourceError(&unsupportedKindError{kind}, source, targetType)
+	}
+
+	// check if there is a built-in converter for well-known source or target types
+	for _, converter := range plan.builtinConverters {
+		ce.trace("built-in converter", source, targetType)
+		result, err := converter(ce, c, source, targetType)
+		if err == nil {
+			return ce.convert(c, result, targetType)
+		}
+		if err != ErrNoConversionAvailable {
+			return nil, err
+		}
+	}
+
+	// check if the source implements encoding.TextMarshaler
+	if plan.textMarshaler {
+		ce.trace("TextMarshaler", source, targetType)
+		return marshalText(source, targetType)
+	}
+
+	// check if the target implements encoding.TextUnmarshaler
+	if plan.textUnmarshaler {
+		ce.trace("TextUnmarshaler", source, targetType)
+		return unmarshalText(source, targetType)
+	}
+
+	// check if the source implements driver.Valuer
+	if plan.valuer {
+		ce.trace("Valuer", source, targetType)
+		value, err := source.(driver.Valuer).Value()
+		if err != nil {
+			return nil, err
+		}
+		return ce.convert(c, value, targetType)
+	}
+
+	// check if the target implements sql.Scanner
+	if plan.scanner {
+		ce.trace("Scanner", source, targetType)
+		return scan(source, targetType)
+	}
+
+	// check if the target implements KeyValueSetter, feeding it the entries of the source map
+	if plan.keyValueSetter {
+		ce.trace("KeyValueSetter", source, targetType)
+		return ce.setKeyValues(c, source, targetType)
+	}
+
+	// Conversion to string using fmt.Stringer
+	if plan.stringer {
+		ce.trace("Stringer", source, targetType)
+		return kind2Exact(source.(fmt.Stringer).String(), targetType), nil
+	}
+
+	// dereference pointer sources, unless the target is a pointer too
+	if sourceType.Kind() == reflect.Ptr && targetType.Kind() != reflect.Ptr {
+		ce.trace("dereference pointer", source, targetType)
+		return ce.convert(c, S.Elem().Interface(), targetType)
+	}
+
+	// allocate pointer targets, converting the source to the pointed-to type.
+	// Pointer chains such as **T are allocated level by level as this recurses
+	if targetType.Kind() == reflect.Ptr {
+		ce.trace("allocate pointer", source, targetType)
+		value, err := ce.convert(c, source, targetType.Elem())
+		if err != nil {
+			return nil, err
+		}
+		T := reflect.New(targetType.Elem())
+		T.Elem().Set(valueOf(value, targetType.Elem()))
+		return T.Interface(), nil
+	}
 
 	// Conversion to string
 	if targetType.Kind() == reflect.String {
-		stringer, ok := source.(fmt.Stringer) // if target implements Stringer, use it.
-		if ok {
-			return kind2Exact(stringer.String(), targetType), nil
-		}
+		ce.trace("format string", source, targetType)
 		// Convert to string typical value types
 		switch sourceType.Kind() {
 		case reflect.Bool:
-			return kind2Exact(strconv.FormatBool(S.Bool()), targetType), nil
+			return kind2Exact(ce.formatBool(S.Bool()), targetType), nil
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 			return kind2Exact(strconv.FormatInt(S.Int(), 10), targetType), nil
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 			return kind2Exact(strconv.FormatUint(S.Uint(), 10), targetType), nil
 		case reflect.Float32, reflect.Float64:
-			return kind2Exact(strconv.FormatFloat(S.Float(), 'g', 6, int(sourceType.Size())*8), targetType), nil
+			return kind2Exact(strconv.FormatFloat(S.Float(), ce.FloatFormat, ce.FloatPrecision, int(sourceType.Size())*8), targetType), nil
+		case reflect.Complex64, reflect.Complex128:
+			return kind2Exact(strconv.FormatComplex(S.Complex(), ce.FloatFormat, ce.FloatPrecision, int(sourceType.Size())*8), targetType), nil
+		case reflect.Slice:
+			if ce.ByteStringEncoding != Raw && isBytes(sourceType) {
+				return kind2Exact(ce.encodeBytes(S.Bytes()), targetType), nil
+			}
+			if ce.Charset != nil && isBytes(sourceType) {
+				decoded, err := ce.Charset.decode(S.Bytes())
+				if err != nil {
+					return nil, err
+				}
+				return kind2Exact(decoded, targetType), nil
+			}
+			if ce.ValidateUTF8 && isBytes(sourceType) && !utf8.Valid(S.Bytes()) {
+				return nil, ErrInvalidUTF8
+			}
+			if isRunes(sourceType) {
+				return kind2Exact(string(S.Convert(runesType).Interface().([]rune)), targetType), nil // encode runes as UTF-8
+			}
+		case reflect.Array:
+			if ce.ByteStringEncoding != Raw && isByteArray(sourceType) {
+				return kind2Exact(ce.encodeBytes(arrayBytes(S)), targetType), nil
+			}
 		}
 
 	}
 
+	// slice to string conversion, joining the elements
+	if ce.JoinSlices && isList(sourceType.Kind()) && targetType.Kind() == reflect.String && !isBytes(sourceType) && !isRunes(sourceType) {
+		ce.trace("join slice", source, targetType)
+		return ce.joinSlice(c, S, targetType)
+	}
+
+	if ce.DisableStringParsing && sourceType.Kind() == reflect.String && isScalar(targetType.Kind()) && targetType.Kind() != reflect.String {
+		return nil, sourceError(ErrIncompatibleType, source, targetType) // strings are not parsed into numbers or bools
+	}
+
 	if sourceType.Kind() == reflect.String {
+		ce.trace("parse string", source, targetType)
 		// Attempt to parse typical value types from the string
+		s := S.String()
+		if isNumber(targetType.Kind()) {
+			if s, err = ce.normalizeNumber(s); err != nil {
+				return nil, parseError(err, targetType)
+			}
+		}
 		switch targetType.Kind() {
 		case reflect.Bool:
-			b, err := strconv.ParseBool(S.String())
+			b, err := ce.parseBool(S.String())
 			if err != nil {
-				return nil, err
+				return nil, parseError(err, targetType)
 			}
 			return kind2Exact(b, targetType), nil
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			i, err := strconv.ParseInt(S.String(), 10, int(targetType.Size())*8)
+			i, err := strconv.ParseInt(s, integerBase(s), 64)
 			if err != nil {
-				return nil, err
+				f, ferr := strconv.ParseFloat(s, 64)
+				if ferr == nil {
+					return ce.convertNumber(reflect.ValueOf(f), targetType) // round the parsed float
+				}
+				if errors.Is(ferr, strconv.ErrRange) {
+					return nil, parseError(ferr, targetType) // a float too large even for float64
+				}
+				return nil, parseError(err, targetType)
 			}
-			return kind2Exact(i, targetType), nil
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			i, err := strconv.ParseUint(S.String(), 10, int(targetType.Size())*8)
+			return ce.convertNumber(reflect.ValueOf(i), targetType)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			u, err := strconv.ParseUint(s, integerBase(s), 64)
 			if err != nil {
-				return nil, err
+				f, ferr := strconv.ParseFloat(s, 64)
+				if ferr == nil {
+					return ce.convertNumber(reflect.ValueOf(f), targetType) // negative number or float to round
+				}
+				if errors.Is(ferr, strconv.ErrRange) {
+					return nil, parseError(ferr, targetType) // a float too large even for float64
+				}
+				return nil, parseError(err, targetType)
 			}
-			return kind2Exact(i, targetType), nil
+			return ce.convertNumber(reflect.ValueOf(u), targetType)
 		case reflect.Float32, reflect.Float64:
-			f, err := strconv.ParseFloat(S.String(), int(targetType.Size())*8)
+			f, err := strconv.ParseFloat(s, int(targetType.Size())*8)
 			if err != nil {
-				return nil, err
+				return nil, parseError(err, targetType)
 			}
 			return kind2Exact(f, targetType), nil
+		case reflect.Complex64, reflect.Complex128:
+			c, err := strconv.ParseComplex(S.String(), int(targetType.Size())*8)
+			if err != nil {
+				return nil, parseError(err, targetType)
+			}
+			return kind2Exact(c, targetType), nil
+		case reflect.Slice:
+			if ce.ByteStringEncoding != Raw && isBytes(targetType) {
+				b, err := ce.decodeBytes(S.String())
+				if err != nil {
+					return nil, parseError(err, targetType)
+				}
+				return kind2Exact(b, targetType), nil
+			}
+			if ce.Charset != nil && isBytes(targetType) {
+				b, err := ce.Charset.encode(S.String())
+				if err != nil {
+					return nil, parseError(err, targetType)
+				}
+				return kind2Exact(b, targetType), nil
+			}
+			if isRunes(targetType) {
+				return kind2Exact([]rune(S.String()), targetType), nil // decode UTF-8 into runes
+			}
+		case reflect.Array:
+			if ce.ByteStringEncoding != Raw && isByteArray(targetType) {
+				b, err := ce.decodeBytes(S.String())
+				if err != nil {
+					return nil, parseError(err, targetType)
+				}
+				return ce.convert(c, b, targetType) // fails if the length doesn't match
+			}
 		}
 	}
 
-	// slice conversion
-	if sourceType.Kind() == reflect.Slice && targetType.Kind() == reflect.Slice {
-		return ce.convertSlice(source, targetType)
+	// string to slice conversion, splitting the string into elements
+	if ce.SplitStrings && sourceType.Kind() == reflect.String && isList(targetType.Kind()) && !isBytes(targetType) && !isRunes(targetType) {
+		ce.trace("split string", source, targetType)
+		return ce.splitString(c, S.String(), targetType)
+	}
+
+	// numeric conversion, checking for overflows
+	if isNumber(sourceType.Kind()) && isNumber(targetType.Kind()) {
+		ce.trace("numeric", source, targetType)
+		return ce.convertNumber(S, targetType)
+	}
+
+	// numeric to bool conversion, where zero is false and anything else is true
+	if isNumber(sourceType.Kind()) && targetType.Kind() == reflect.Bool {
+		ce.trace("number to bool", source, targetType)
+		return kind2Exact(!isZeroNumber(S), targetType), nil
+	}
+
+	// bool to numeric conversion, where false is 0 and true is 1
+	if sourceType.Kind() == reflect.Bool && isNumber(targetType.Kind()) {
+		ce.trace("bool to number", source, targetType)
+		return ce.boolToNumber(S.Bool(), targetType)
+	}
+
+	// complex conversion, from real or complex numbers
+	if (isNumber(sourceType.Kind()) || isComplex(sourceType.Kind())) && isComplex(targetType.Kind()) {
+		ce.trace("complex", source, targetType)
+		return convertComplex(S, targetType)
+	}
+
+	// slice and array conversion
+	if isList(sourceType.Kind()) && isList(targetType.Kind()) {
+		ce.trace("slice", source, targetType)
+		return ce.convertSlice(c, source, targetType)
 	}
 
 	// map conversion
 	if sourceType.Kind() == reflect.Map && targetType.Kind() == reflect.Map {
-		return ce.convertMap(source, targetType)
+		ce.trace("map", source, targetType)
+		return ce.convertMap(c, source, targetType)
+	}
+
+	// map to slice of entries conversion
+	if sourceType.Kind() == reflect.Map && isList(targetType.Kind()) && isEntryType(targetType.Elem()) {
+		ce.trace("map to slice", source, targetType)
+		return ce.convertMapToSlice(c, source, targetType)
+	}
+
+	// slice of entries to map conversion
+	if isList(sourceType.Kind()) && targetType.Kind() == reflect.Map {
+		ce.trace("slice to map", source, targetType)
+		return ce.convertSliceToMap(c, source, targetType)
+	}
+
+	// map to struct conversion
+	if sourceType.Kind() == reflect.Map && targetType.Kind() == reflect.Struct && ce.hasMappedFields(targetType) {
+		ce.trace("map to struct", source, targetType)
+		return ce.convertMapToStruct(c, source, targetType)
+	}
+
+	// struct to map conversion
+	if sourceType.Kind() == reflect.Struct && targetType.Kind() == reflect.Map && targetType.Key().Kind() == reflect.String && ce.hasMappedFields(sourceType) {
+		ce.trace("struct to map", source, targetType)
+		return ce.convertStructToMap(c, source, targetType, false)
+	}
+
+	// positional slice to struct conversion
+	if isList(sourceType.Kind()) && targetType.Kind() == reflect.Struct && ce.hasMappedFields(targetType) {
+		ce.trace("slice to struct", source, targetType)
+		return ce.convertSliceToStruct(c, source, targetType)
+	}
+
+	// positional struct to slice conversion
+	if sourceType.Kind() == reflect.Struct && isList(targetType.Kind()) && ce.hasMappedFields(sourceType) {
+		ce.trace("struct to slice", source, targetType)
+		return ce.convertStructToSlice(c, source, targetType)
 	}
 
 	// reflection-based conversion
 	if reflect.TypeOf(source).ConvertibleTo(targetType) {
+		ce.trace("reflection", source, targetType)
 		return S.Convert(targetType).Interface(), nil
 	}
 
+	// struct conversion, matching fields by name
+	if sourceType.Kind() == reflect.Struct && targetType.Kind() == reflect.Struct {
+		ce.trace("struct", source, targetType)
+		return ce.convertStruct(c, source, targetType)
+	}
+
+	// scalar to single element slice conversion
+	if ce.WrapScalarsIntoSlices && isScalar(sourceType.Kind()) && targetType.Kind() == reflect.Slice {
+		ce.trace("wrap scalar", source, targetType)
+		return ce.wrapScalar(c, S, targetType)
+	}
+
+	// single element slice to scalar conversion
+	if ce.UnwrapSingleElementSlices && isList(sourceType.Kind()) && isScalar(targetType.Kind()) {
+		ce.trace("unwrap slice", source, targetType)
+		return ce.unwrapSlice(c, S, targetType)
+	}
+
+	// last resort
+	if ce.fallbackConverter != nil {
+		ce.trace("fallback converter", source, targetType)
+		result, err := ce.fallbackConverter(source, targetType)
+		if err == nil {
+			return ce.convert(c, result, targetType)
+		}
+		if err != ErrNoConversionAvailable {
+			return nil, err
+		}
+	}
+
 	// no luck
-	return nil, ErrIncompatibleType
+	ce.trace("incompatible", source, targetType)
+	return nil, sourceError(ErrIncompatibleType, source, targetType)
 }
 
 // Set sets the given target pointer to sourcevalue, performing
 // any type conversion necessary
 func (ce *ConverterEngine) Set(target, source interface{}) error {
+	return ce.set(new(conversion), target, source)
+}
+
+// set sets the given target pointer to source value as part of the given conversion
+func (ce *ConverterEngine) set(c *conversion, target, source interface{}) error {
 	T := reflect.ValueOf(target)
 	if T.Kind() != reflect.Ptr {
 		return ErrExpectedPointer
 	}
-	T = T.Elem()
+	if T.IsNil() {
+		return ErrNilPointer
+	}
+	return ce.setValue(c, T.Elem(), source)
+}
+
+// SetValue sets the given settable value, such as a struct field obtained through reflection,
+// to source value, performing any type conversion necessary
+func (ce *ConverterEngine) SetValue(target reflect.Value, source interface{}) error {
+	return ce.setValue(new(conversion), target, source)
+}
 
-	converted, err := ce.Convert(source, T.Type())
+// setValue sets the given settable value to source value as part of the given conversion
+func (ce *ConverterEngine) setValue(c *conversion, T reflect.Value, source interface{}) error {
+	if !T.CanSet() {
+		return ErrNotSettable
+	}
+
+	converted, err := ce.convert(c, source, T.Type())
 	if err != nil {
 		return err
 	}
-	T.Set(reflect.ValueOf(converted))
+	T.Set(valueOf(converted, T.Type()))
 	return nil
 }
 
+// MustConvert converts the source value to the given target type
+// and panics if the conversion fails
+func (ce *ConverterEngine) MustConvert(source interface{}, targetType reflect.Type) interface{} {
+	result, err := ce.Convert(source, targetType)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// MustSet sets the given target pointer to source value, performing
+// any type conversion necessary, and panics if it fails
+func (ce *ConverterEngine) MustSet(target, source interface{}) {
+	if err := ce.Set(target, source); err != nil {
+		panic(err)
+	}
+}
+
+// ConvertWithDefault converts the source value to the given target type, returning def converted
+// to the target type if the conversion fails. If def can't be converted either, the zero value of
+// the target type is returned
+func (ce *ConverterEngine) ConvertWithDefault(source interface{}, targetType reflect.Type, def interface{}) interface{} {
+	result, err := ce.Convert(source, targetType)
+	if err == nil {
+		return result
+	}
+	result, err = ce.Convert(def, targetType)
+	if err == nil {
+		return result
+	}
+	return reflect.Zero(targetType).Interface()
+}
+
+// SetWithDefault sets the given target pointer to source value, performing any type conversion necessary.
+// If the conversion fails, the target is set to def instead. Only returns an error if def can't be set either
+func (ce *ConverterEngine) SetWithDefault(target, source, def interface{}) error {
+	if err := ce.Set(target, source); err == nil {
+		return nil
+	}
+	return ce.Set(target, def)
+}
+
 // Convert attempts to convert the source value to the given target type using the default engine
 // if it does not fail, the returned value is guaranteed to be of the target type
 func Convert(source interface{}, targetType reflect.Type) (interface{}, error) {
 	return Default.Convert(source, targetType)
 }
 
+// ConvertLike attempts to convert the source value to the type of the given sample value using the default engine
+func ConvertLike(source, sample interface{}) (interface{}, error) {
+	return Default.ConvertLike(source, sample)
+}
+
 // Set sets the given target pointer to source value using the default engine
 // performing any type conversion necessary
 func Set(target, source interface{}) error {
 	return Default.Set(target, source)
 }
+
+// SetValue sets the given settable value to source value using the default engine
+// performing any type conversion necessary
+func SetValue(target reflect.Value, source interface{}) error {
+	return Default.SetValue(target, source)
+}
+
+// ConvertSlice converts a slice or array to the given target type using the default engine.
+// The target type must be a slice or an array
+func ConvertSlice(source interface{}, targetType reflect.Type) (interface{}, error) {
+	return Default.ConvertSlice(source, targetType)
+}
+
+// ConvertMap converts a map to the given target type using the default engine.
+// The target type must be a map
+func ConvertMap(source interface{}, targetType reflect.Type) (interface{}, error) {
+	return Default.ConvertMap(source, targetType)
+}
+
+// ConvertAll converts every source value to the given target type using the default engine,
+// attempting all of them even if some fail
+func ConvertAll(sources []interface{}, targetType reflect.Type) ([]interface{}, []error) {
+	return Default.ConvertAll(sources, targetType)
+}
+
+// ConvertStream converts the elements of a slice or array one at a time to the given element type
+// using the default engine, passing each of them to yield
+func ConvertStream(source interface{}, targetElemType reflect.Type, yield func(i int, v interface{}) error) error {
+	return Default.ConvertStream(source, targetElemType, yield)
+}
+
+// MustConvert converts the source value to the given target type using the default engine
+// and panics if the conversion fails
+func MustConvert(source interface{}, targetType reflect.Type) interface{} {
+	return Default.MustConvert(source, targetType)
+}
+
+// MustSet sets the given target pointer to source value using the default engine
+// and panics if it fails
+func MustSet(target, source interface{}) {
+	Default.MustSet(target, source)
+}
+
+// ConvertWithDefault converts the source value to the given target type using the default engine,
+// returning def converted to the target type if the conversion fails
+func ConvertWithDefault(source interface{}, targetType reflect.Type, def interface{}) interface{} {
+	return Default.ConvertWithDefault(source, targetType, def)
+}
+
+// SetWithDefault sets the given target pointer to source value using the default engine,
+// or to def if the conversion fails
+func SetWithDefault(target, source, def interface{}) error {
+	return Default.SetWithDefault(target, source, def)
+}
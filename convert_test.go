@@ -1,12 +1,20 @@
 package elastic_test
 
 import (
+	"bytes"
 	"encoding/binary"
+	"encoding/gob"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"math/big"
+	"net/url"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+	"unsafe"
 
 	"github.com/epiclabs-io/elastic"
 
@@ -24,6 +32,24 @@ type TestStruct struct {
 	Y int
 }
 
+type Point32 struct {
+	X int32
+	Y int32
+}
+
+type Segment struct {
+	Name  string
+	Start Point32
+	End   Point32
+}
+
+type Point3D struct {
+	X float64
+	Y string
+	Z int
+	w int
+}
+
 func (ts *TestStruct) String() string {
 	return fmt.Sprintf("(%d, %d)", ts.X, ts.Y)
 }
@@ -36,6 +62,20 @@ func (ts *TestStruct) ConvertTo(targetType reflect.Type) (interface{}, error) {
 	return nil, elastic.ErrNoConversionAvailable
 }
 
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+// implement the ConverterFrom interface
+func (p *Point32) ConvertFrom(source interface{}) error {
+	s, ok := source.(string)
+	if !ok {
+		return elastic.ErrNoConversionAvailable
+	}
+	_, err := fmt.Sscanf(s, "(%d, %d)", &p.X, &p.Y)
+	return err
+}
+
 type ConversionTest struct {
 	source         interface{}
 	expectedResult interface{}
@@ -91,9 +131,84 @@ var testData = []ConversionTest{
 	{float64(19.3), "19.3", nil},
 	{float32(-9.2), "-9.2", nil},
 	{float64(-19.3), "-19.3", nil},
-	{int(-1), uint(0xffffffffffffffff), nil},
+	{int(-1), uint(0), elastic.ErrOverflow}, // test negative values can't become unsigned
+	{int64(300), int8(0), elastic.ErrOverflow},
+	{int64(100), int8(100), nil},
+	{uint64(math.MaxUint64), int64(0), elastic.ErrOverflow},
+	{uint16(256), uint8(0), elastic.ErrOverflow},
+	{int16(-129), int8(0), elastic.ErrOverflow},
+	{float64(1e10), int32(0), elastic.ErrOverflow},
+	{float64(-1.5), uint(0), elastic.ErrOverflow},
+	{float64(1e40), float32(0), elastic.ErrOverflow},
+	{"300", int8(0), elastic.ErrOverflow},
+	{"-1", uint8(0), elastic.ErrOverflow},
+	{"99999999999999999999", int64(0), elastic.ErrOverflow},
+	{"1e40", float32(0), elastic.ErrOverflow},
+	{"1e400", int64(0), elastic.ErrOverflow}, // test floats beyond the float64 range overflow integers too
+	{"-1e400", uint(0), elastic.ErrOverflow},
+	{float64(194.20000000001), "194.20000000001", nil}, // test float formatting does not lose precision
+	{float32(0.1), "0.1", nil},
+	{complex(3, 4), "(3+4i)", nil}, // test complex numbers
+	{complex64(complex(-1.5, 2)), "(-1.5+2i)", nil},
+	{"(3+4i)", complex(3, 4), nil},
+	{"(1-2i)", complex64(complex(1, -2)), nil},
+	{"5", complex(5, 0), nil},
+	{"x", complex(0, 0), ErrAny},
+	{3, complex(3, 0), nil},
+	{uint8(3), complex64(complex(3, 0)), nil},
+	{2.5, complex(2.5, 0), nil},
+	{complex64(complex(1, 2)), complex(1, 2), nil},
+	{complex(1e300, 0), complex64(0), elastic.ErrOverflow},
+	{complex(3, 4), 5, elastic.ErrIncompatibleType},
+	{"0xFF", 255, nil}, // test parsing prefixed bases
+	{"-0x10", int8(-16), nil},
+	{"0o17", uint16(15), nil},
+	{"0b1010", int64(10), nil},
+	{"42", 42, nil},
+	{"010", 10, nil}, // leading zeros are not octal
+	{"0xZZ", 0, ErrAny},
+	{"0x100", uint8(0), elastic.ErrOverflow},
+	{time.Unix(1500000000, 0), int64(1500000000), nil}, // test time.Time to Unix seconds
+	{time.Unix(1500000000, 0), float64(1500000000), nil},
+	{int64(1500000000), time.Unix(1500000000, 0), nil}, // test Unix seconds to time.Time
+	{uint32(1500000000), time.Unix(1500000000, 0), nil},
+	{1500000000.5, time.Unix(1500000000, 500000000), nil},
+	{"2017-07-14T02:40:00Z", time.Date(2017, 7, 14, 2, 40, 0, 0, time.UTC), nil}, // test RFC3339 parsing
+	{time.Date(2017, 7, 14, 2, 40, 0, 0, time.UTC), "2017-07-14T02:40:00Z", nil},
+	{"yesterday", time.Time{}, ErrAny},
+	{"1h30m", 90 * time.Minute, nil}, // test duration parsing
+	{90 * time.Minute, "1h30m0s", nil},
+	{"1500", 1500 * time.Nanosecond, nil}, // test plain numbers are nanoseconds
+	{int64(1500), 1500 * time.Nanosecond, nil},
+	{1500 * time.Millisecond, int64(1500000000), nil},
+	{"forever", time.Duration(0), ErrAny},
+	{float64Ptr(5.5), 5, nil}, // test pointer dereference
+	{&[]*float64{float64Ptr(1.5)}, []string{"1.5"}, nil},
+	{(*float64)(nil), 0, nil},     // test nil pointers are treated as nil
+	{(*TestStruct)(nil), "", nil}, // test nil pointers do not invoke methods
+	{&TestStruct{X: 5, Y: 7}, Point32{X: 5, Y: 7}, nil},
+	{"(5, 7)", Point32{X: 5, Y: 7}, nil},                               // test ConverterFrom implementation
+	{"(5, x)", Point32{}, ErrAny},                                      // test ConverterFrom implementation failure
+	{[]interface{}{"1.5", "a", 2}, Point3D{X: 1.5, Y: "a", Z: 2}, nil}, // test positional slice to struct
+	{[]string{"1"}, Point32{X: 1}, nil},                                // test positional slice to struct with fewer elements
+	{[3]int{1, 2, 3}, Point32{}, elastic.ErrLengthMismatch},
+	{Point3D{X: 1.5, Y: "2", Z: 3, w: 4}, []string{"1.5", "2", "3"}, nil}, // test positional struct to slice
+	{Point32{X: 1, Y: 2}, [2]float64{1, 2}, nil},
+	{Point32{X: 1, Y: 2}, [3]float64{}, elastic.ErrLengthMismatch},
+	{[]interface{}{1, "2", 3.0}, [3]float64{1, 2, 3}, nil}, // test slice to array
+	{[4]byte{1, 2, 3, 4}, []int{1, 2, 3, 4}, nil},          // test array to slice
+	{[3]int{1, 2, 3}, []string{"1", "2", "3"}, nil},
+	{[0]int{}, []string{}, nil},
+	{[2]string{"1", "2"}, [2]int{1, 2}, nil}, // test array to array
+	{[]int{1, 2}, [3]int{}, elastic.ErrLengthMismatch},
+	{[2]int{1, 2}, [3]int{}, elastic.ErrLengthMismatch},
 	{"true", true, nil},
 	{"false", false, nil},
+	{"YES", true, nil}, // test flexible boolean parsing
+	{"off", false, nil},
+	{"N", false, nil},
+	{"True", true, nil},
+	{"maybe", false, ErrAny},
 	{true, "true", nil},
 	{false, "false", nil},
 	{5, float32(5), nil},
@@ -139,7 +254,15 @@ var testData = []ConversionTest{
 	{"XYZ", float64(19.3), ErrAny},
 	{"XYZ", float32(-9.2), ErrAny},
 	{"XYZ", float64(-19.3), ErrAny},
-	{true, 7, ErrAny},
+	{true, 1, nil}, // test bool to numeric conversion
+	{false, 0, nil},
+	{true, float32(1), nil},
+	{false, uint8(0), nil},
+	{0, false, nil}, // test numeric to bool conversion
+	{-3, true, nil},
+	{uint(0), false, nil},
+	{0.5, true, nil},
+	{0.0, false, nil},
 	{ConversionTest{}, 4, elastic.ErrIncompatibleType},
 	{&TestStruct{X: 5, Y: 7}, "(5, 7)", nil},                      // test fmt.Stringer
 	{&TestStruct{X: 5, Y: 7}, float64(8.602325267042627), nil},    // Test Converter implementation
@@ -147,11 +270,15 @@ var testData = []ConversionTest{
 	{&TestStruct{X: 5, Y: 7}, StringAlias("(5, 7)"), nil},         // test fmt.Stringer implementation to an alias type
 	{[]byte{0, 0, 0, 1, 0, 0, 0, 2}, TestStruct{X: 1, Y: 2}, nil}, // Test Target converter
 	{&TestStruct{X: 5, Y: 7}, 99, elastic.ErrIncompatibleType},
-	{FloatAlias(2.2), int(2), nil},      // test Source converter
-	{FloatAlias(2.7), int(3), nil},      // test Source converter
-	{FloatAlias(2.7), IntAlias(3), nil}, // test Source converter
-	{float32(5.5), float64(5.5), nil},   // test upgrade/downgrade
-	{float64(5.5), float32(5.5), nil},   // test upgrade/downgrade
+	{FloatAlias(2.2), int(2), nil},                                   // test Source converter
+	{FloatAlias(2.7), int(3), nil},                                   // test Source converter
+	{FloatAlias(2.7), IntAlias(3), nil},                              // test Source converter
+	{float32(5.5), float64(5.5), nil},                                // test upgrade/downgrade
+	{float64(5.5), float32(5.5), nil},                                // test upgrade/downgrade
+	{TestStruct{X: 5, Y: 7}, Point32{X: 5, Y: 7}, nil},               // test struct to struct conversion
+	{TestStruct{X: 5, Y: 7}, Point3D{X: 5, Y: "7"}, nil},             // test struct to struct with missing fields
+	{Point3D{X: 1, Y: "2", Z: 3, w: 4}, TestStruct{X: 1, Y: 2}, nil}, // test struct to struct with extra fields
+	{Point3D{X: 1, Y: "XYZ", Z: 3}, TestStruct{}, ErrAny},            // test struct to struct with unconvertible fields
 }
 
 func TestConvert(tx *testing.T) {
@@ -199,7 +326,7 @@ func TestConvert(tx *testing.T) {
 			if ct.expectedError == ErrAny {
 				t.MustFail(err, "Conversion should have failed")
 			} else {
-				t.MustFailWith(err, ct.expectedError)
+				t.Assert(errors.Is(err, ct.expectedError), "Expected error to be '%v'. Got '%v'", ct.expectedError, err)
 			}
 		}
 
@@ -213,7 +340,7 @@ func TestConvert(tx *testing.T) {
 			if ct.expectedError == ErrAny {
 				t.MustFail(err, "Conversion should have failed")
 			} else {
-				t.MustFailWith(err, ct.expectedError)
+				t.Assert(errors.Is(err, ct.expectedError), "Expected error to be '%v'. Got '%v'", ct.expectedError, err)
 			}
 		}
 	}
@@ -223,4 +350,1893 @@ func TestConvert(tx *testing.T) {
 	err := elastic.Set(x, 4)
 	t.MustFailWith(err, elastic.ErrExpectedPointer)
 
+	// Test `Set` fails when the first parameter is a nil pointer
+	var p *int
+	err = elastic.Set(p, 4)
+	t.MustFailWith(err, elastic.ErrNilPointer)
+
+}
+
+type TaggedStruct struct {
+	UserName string `elastic:"user_name" json:"name"`
+	Age      int    `json:"age,omitempty"`
+	Password string `elastic:"-" json:"-"`
+}
+
+func TestMapToStruct(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	// field values are coerced to the field types
+	var p Point3D
+	t.Ok(elastic.Set(&p, map[string]interface{}{"X": "1.5", "Y": 2, "Z": 3.0}))
+	t.Equals(Point3D{X: 1.5, Y: "2", Z: 3}, p)
+
+	// missing keys leave the fields to their zero value, and extra or unexported keys are ignored
+	p = Point3D{}
+	t.Ok(elastic.Set(&p, map[string]string{"Z": "7", "W": "1", "w": "1"}))
+	t.Equals(Point3D{Z: 7}, p)
+
+	// nested maps populate nested structs, pointers and slices of structs
+	type Route struct {
+		Name  string
+		From  Point32
+		To    *Point32
+		Stops []Point3D
+	}
+	var route Route
+	t.Ok(elastic.Set(&route, map[string]interface{}{
+		"Name":  "A",
+		"From":  map[string]interface{}{"X": "1", "Y": 2},
+		"To":    map[string]float64{"X": 3},
+		"Stops": []interface{}{map[string]interface{}{"Y": "stop"}},
+	}))
+	t.Equals(Route{Name: "A", From: Point32{X: 1, Y: 2}, To: &Point32{X: 3}, Stops: []Point3D{{Y: "stop"}}}, route)
+
+	// values that can't be coerced fail, reporting the path to the field
+	err := elastic.Set(&route, map[string]interface{}{"From": map[string]interface{}{"Y": "north"}})
+	t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("From.Y", conversionError.Path)
+}
+
+func TestStructToMap(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	segment := Segment{Name: "s", Start: Point32{X: 1, Y: 2}, End: Point32{X: 3, Y: 4}}
+
+	// nested structs become nested maps of the same element type
+	var m map[string]interface{}
+	t.Ok(elastic.Set(&m, segment))
+	t.Equals(map[string]interface{}{
+		"Name":  "s",
+		"Start": map[string]interface{}{"X": int32(1), "Y": int32(2)},
+		"End":   map[string]interface{}{"X": int32(3), "Y": int32(4)},
+	}, m)
+
+	// field values are converted to the map element type
+	var points map[string]map[string]float64
+	t.Ok(elastic.Set(&points, struct{ Start, End Point32 }{segment.Start, segment.End}))
+	t.Equals(map[string]map[string]float64{"Start": {"X": 1, "Y": 2}, "End": {"X": 3, "Y": 4}}, points)
+
+	// nested maps convert back to the original struct
+	var back Segment
+	t.Ok(elastic.Set(&back, m))
+	t.Equals(segment, back)
+
+	// fields that can't be converted to the element type fail, reporting the path to the field
+	var ints map[string]int
+	err := elastic.Set(&ints, segment)
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError, got %v", err)
+	t.Equals("Name", conversionError.Path)
+
+	// structs without fields to map, or converted by the engine out of the box, don't convert to maps and slices
+	mapType := reflect.TypeOf(map[string]interface{}{})
+	sliceType := reflect.TypeOf([]interface{}{})
+	unexported := struct{ x int }{1}
+	for _, source := range []interface{}{time.Now(), unexported, struct{}{}} {
+		t.StartSubTest("Conversion of %T", source)
+		_, err = elastic.Convert(source, mapType)
+		t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+		_, err = elastic.Convert(source, sliceType)
+		t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+		_, err = elastic.Convert(map[string]interface{}{"x": 1}, reflect.TypeOf(source))
+		t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+	}
+	t.Assert(!elastic.CanConvert(reflect.TypeOf(unexported), mapType), "Expected structs without fields not to convert to maps")
+	t.Assert(!elastic.CanConvert(reflect.TypeOf(unexported), sliceType), "Expected structs without fields not to convert to slices")
+	t.Assert(!elastic.CanConvert(mapType, reflect.TypeOf(unexported)), "Expected maps not to convert to structs without fields")
+
+	// such fields are kept as they are in nested maps
+	t.Ok(elastic.Set(&m, struct{ Empty struct{} }{}))
+	t.Equals(map[string]interface{}{"Empty": struct{}{}}, m)
+}
+
+func TestStructTags(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	source := map[string]interface{}{
+		"user_name": "john",
+		"name":      "paul",
+		"Age":       "30",
+		"age":       "40",
+		"Password":  "secret",
+	}
+
+	// default engine uses the `elastic` tag key
+	engine := elastic.New()
+	var ts TaggedStruct
+	err := engine.Set(&ts, source)
+	t.Ok(err)
+	t.Equals(TaggedStruct{UserName: "john", Age: 30}, ts)
+
+	m, err := engine.Convert(TaggedStruct{UserName: "john", Age: 30, Password: "secret"}, reflect.TypeOf(map[string]interface{}{}))
+	t.Ok(err)
+	t.Equals(map[string]interface{}{"user_name": "john", "Age": 30}, m)
+
+	// reuse json tags
+	engine.TagKey = "json"
+	err = engine.Set(&ts, source)
+	t.Ok(err)
+	t.Equals(TaggedStruct{UserName: "paul", Age: 40}, ts)
+
+	m, err = engine.Convert(TaggedStruct{UserName: "paul", Age: 40, Password: "secret"}, reflect.TypeOf(map[string]interface{}{}))
+	t.Ok(err)
+	t.Equals(map[string]interface{}{"name": "paul", "age": 40}, m)
+}
+
+func TestFieldConverter(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	userType := reflect.TypeOf(User{})
+	engine := elastic.New()
+	engine.AddFieldConverter(userType, "Name", func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		if s, ok := source.(string); ok {
+			return strings.ToUpper(s), nil
+		}
+		return nil, elastic.ErrNoConversionAvailable
+	})
+	engine.AddFieldConverter(userType, "Age", func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		if source == "old" {
+			return nil, ErrAny
+		}
+		return nil, elastic.ErrNoConversionAvailable
+	})
+
+	var user User
+	t.Ok(engine.Set(&user, map[string]interface{}{"Name": "john", "Age": "30"}))
+	t.Equals(User{Name: "JOHN", Age: 30}, user)
+	t.Ok(engine.Set(&user, struct{ Name string }{"paul"}))
+	t.Equals(User{Name: "PAUL"}, user)
+	t.Ok(engine.Set(&user, []interface{}{5, 40}))
+	t.Equals(User{Name: "5", Age: 40}, user)
+
+	err := engine.Set(&user, map[string]interface{}{"Age": "old"})
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("Age", conversionError.Path)
+	t.Assert(errors.Is(err, ErrAny), "Expected the field converter error, got %v", err)
+
+	// other struct types and engines are unaffected
+	var p Point3D
+	t.Ok(engine.Set(&p, map[string]interface{}{"Y": "y"}))
+	t.Equals(Point3D{Y: "y"}, p)
+	t.Ok(elastic.Set(&user, map[string]interface{}{"Name": "john"}))
+	t.Equals(User{Name: "john"}, user)
+
+	clone := engine.Clone()
+	engine.RemoveFieldConverter(userType, "Name")
+	t.Ok(engine.Set(&user, map[string]interface{}{"Name": "john"}))
+	t.Equals(User{Name: "john"}, user)
+	t.Ok(clone.Set(&user, map[string]interface{}{"Name": "john"}))
+	t.Equals(User{Name: "JOHN"}, user)
+	clone.Reset()
+	t.Ok(clone.Set(&user, map[string]interface{}{"Name": "john"}))
+	t.Equals(User{Name: "john"}, user)
+}
+
+func TestRoundingMode(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	tests := []struct {
+		mode     elastic.RoundingMode
+		source   interface{}
+		expected int
+	}{
+		{elastic.Truncate, 2.5, 2},
+		{elastic.Truncate, -2.7, -2},
+		{elastic.Round, 2.5, 3},
+		{elastic.Round, -2.5, -3},
+		{elastic.Round, "2.4", 2},
+		{elastic.Floor, 2.7, 2},
+		{elastic.Floor, -2.2, -3},
+		{elastic.Ceil, 2.2, 3},
+		{elastic.Ceil, "-2.7", -2},
+		{elastic.RoundHalfEven, 2.5, 2},
+		{elastic.RoundHalfEven, 3.5, 4},
+		{elastic.RoundHalfEven, "-2.5", -2},
+	}
+
+	engine := elastic.New()
+	for _, test := range tests {
+		t.StartSubTest("Rounding %v with mode %d", test.source, test.mode)
+		engine.RoundingMode = test.mode
+		var i int
+		err := engine.Set(&i, test.source)
+		t.Ok(err)
+		t.Equals(test.expected, i)
+	}
+}
+
+func TestStrict(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	engine := elastic.New()
+	engine.Strict = true
+	intType := reflect.TypeOf(0)
+
+	t.Equals(5, engine.MustConvert(5.0, intType))
+	t.Equals(uint8(5), engine.MustConvert("5.0", reflect.TypeOf(uint8(0))))
+	t.Equals(5.5, engine.MustConvert(float32(5.5), reflect.TypeOf(0.0)))
+
+	_, err := engine.Convert(5.5, intType)
+	t.Assert(errors.Is(err, elastic.ErrPrecisionLoss), "Expected precision loss, got %v", err)
+	_, err = engine.Convert("-2.5", intType)
+	t.Assert(errors.Is(err, elastic.ErrPrecisionLoss), "Expected precision loss, got %v", err)
+	_, err = engine.Convert(0.5, reflect.TypeOf(new(big.Int)))
+	t.Assert(errors.Is(err, elastic.ErrPrecisionLoss), "Expected precision loss, got %v", err)
+	_, err = engine.Convert(-1, reflect.TypeOf(uint(0)))
+	t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected overflow, got %v", err)
+	_, err = engine.Convert(300, reflect.TypeOf(int8(0)))
+	t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected overflow, got %v", err)
+
+	// conversions to floats still round to the nearest float
+	t.Equals(float64(1<<53), engine.MustConvert(int64(1<<53+1), reflect.TypeOf(0.0)))
+	t.Equals(float32(0.1), engine.MustConvert(0.1, reflect.TypeOf(float32(0))))
+}
+
+type Timeout int64
+
+func TestNamedNumericTypes(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	timeoutType := reflect.TypeOf(Timeout(0))
+	t.Equals(Timeout(1000000000), elastic.MustConvert(time.Second, timeoutType))
+	t.Equals(time.Duration(5), elastic.MustConvert(Timeout(5), reflect.TypeOf(time.Duration(0))))
+	t.Equals(Timeout(30), elastic.MustConvert("30", timeoutType))
+	t.Equals(Timeout(2), elastic.MustConvert(FloatAlias(2.7), timeoutType))
+	t.Equals(FloatAlias(3), elastic.MustConvert(Timeout(3), reflect.TypeOf(FloatAlias(0))))
+	t.Equals(StringAlias("3"), elastic.MustConvert(Timeout(3), reflect.TypeOf(StringAlias(""))))
+	t.Equals(true, elastic.MustConvert(Timeout(3), reflect.TypeOf(false)))
+
+	engine := elastic.New()
+	engine.RoundingMode = elastic.Round
+	t.Equals(Timeout(3), engine.MustConvert("2.5", timeoutType))
+	t.Equals(IntAlias(3), engine.MustConvert(FloatAlias(2.5), reflect.TypeOf(IntAlias(0))))
+
+	_, err := elastic.Convert(time.Second, reflect.TypeOf(int8(0)))
+	t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected overflow, got %v", err)
+	_, err = elastic.Convert(Timeout(-1), reflect.TypeOf(uint(0)))
+	t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected overflow, got %v", err)
+}
+
+func TestTimeLayout(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	engine := elastic.New()
+	engine.TimeLayout = "2006-01-02"
+
+	var tm time.Time
+	err := engine.Set(&tm, "2019-10-25")
+	t.Ok(err)
+	t.Equals(time.Date(2019, 10, 25, 0, 0, 0, 0, time.UTC), tm)
+
+	var s string
+	err = engine.Set(&s, tm)
+	t.Ok(err)
+	t.Equals("2019-10-25", s)
+
+	err = engine.Set(&tm, "2017-07-14T02:40:00Z")
+	t.MustFail(err, "Expected parsing to fail with a different layout")
+
+	// time.Time fields are not flattened into maps
+	m, err := engine.Convert(struct{ Date time.Time }{tm}, reflect.TypeOf(map[string]interface{}{}))
+	t.Ok(err)
+	t.Equals(map[string]interface{}{"Date": tm}, m)
+}
+
+type Event struct {
+	Created time.Time  `elastic:"created,layout=2006-01-02"`
+	Updated *time.Time `elastic:",layout=Jan 2, 2006"`
+	Deleted time.Time  `elastic:"deleted"`
+}
+
+func TestTimeLayoutTag(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	var event Event
+	err := elastic.Set(&event, map[string]interface{}{
+		"created": "2019-10-25",
+		"Updated": "Oct 26, 2019",
+		"deleted": "2019-10-27T10:00:00Z",
+	})
+	t.Ok(err)
+	t.Equals(time.Date(2019, 10, 25, 0, 0, 0, 0, time.UTC), event.Created)
+	t.Equals(time.Date(2019, 10, 26, 0, 0, 0, 0, time.UTC), *event.Updated)
+	t.Equals(time.Date(2019, 10, 27, 10, 0, 0, 0, time.UTC), event.Deleted)
+
+	// non-string sources are converted as usual
+	err = elastic.Set(&event, map[string]interface{}{"created": 0})
+	t.Ok(err)
+	t.Equals(time.Unix(0, 0), event.Created)
+
+	err = elastic.Set(&event, map[string]interface{}{"created": "25/10/2019"})
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("created", conversionError.Path)
+	t.Assert(strings.Contains(err.Error(), `"2006-01-02"`), "Expected the error to name the layout, got %v", err)
+
+	// layouts are used to format the fields to strings too, so that they round trip
+	updated := time.Date(2019, 10, 26, 0, 0, 0, 0, time.UTC)
+	event = Event{Created: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Updated: &updated, Deleted: time.Date(2020, 1, 3, 4, 5, 6, 0, time.UTC)}
+	m := elastic.MustConvert(event, reflect.TypeOf(map[string]string{})).(map[string]string)
+	t.Equals(map[string]string{"created": "2020-01-02", "Updated": "Oct 26, 2019", "deleted": "2020-01-03T04:05:06Z"}, m)
+	var roundTrip Event
+	t.Ok(elastic.Set(&roundTrip, m))
+	t.Equals(event, roundTrip)
+
+	values := elastic.MustConvert(event, reflect.TypeOf(url.Values{})).(url.Values)
+	t.Equals("2020-01-02", values.Get("created"))
+	t.Equals("Oct 26, 2019", values.Get("Updated"))
+	roundTrip = Event{}
+	t.Ok(elastic.Set(&roundTrip, values))
+	t.Equals(event, roundTrip)
+
+	// maps of interfaces keep the time values
+	t.Equals(event.Created, elastic.MustConvert(event, reflect.TypeOf(map[string]interface{}{})).(map[string]interface{})["created"])
+}
+
+func TestNilSource(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	tests := []interface{}{
+		0,
+		"",
+		false,
+		(*int)(nil),
+		[]int(nil),
+		map[string]int(nil),
+		TestStruct{},
+	}
+
+	for _, expected := range tests {
+		t.StartSubTest("Conversion of nil to %s", reflect.TypeOf(expected))
+		r, err := elastic.Convert(nil, reflect.TypeOf(expected))
+		t.Ok(err)
+		t.Equals(expected, r)
+	}
+
+	var e error = errors.New("some error")
+	err := elastic.Set(&e, nil)
+	t.Ok(err)
+	t.Equals(nil, e)
+
+	// nil values within collections (e.g. JSON nulls)
+	var ints []int
+	err = elastic.Set(&ints, []interface{}{1, nil, 3})
+	t.Ok(err)
+	t.Equals([]int{1, 0, 3}, ints)
+
+	var m map[string]interface{}
+	err = elastic.Set(&m, map[string]interface{}{"a": nil})
+	t.Ok(err)
+	t.Equals(map[string]interface{}{"a": nil}, m)
+
+	var p Point3D
+	err = elastic.Set(&p, map[string]interface{}{"X": nil, "Y": "y"})
+	t.Ok(err)
+	t.Equals(Point3D{Y: "y"}, p)
+}
+
+func TestPointerTarget(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	var p *int
+	err := elastic.Set(&p, "5")
+	t.Ok(err)
+	t.Assert(p != nil, "Expected pointer to be allocated")
+	t.Equals(5, *p)
+
+	err = elastic.Set(&p, nil)
+	t.Ok(err)
+	t.Assert(p == nil, "Expected pointer to be nil")
+
+	f := 2.0
+	var ps *StringAlias
+	err = elastic.Set(&ps, &f)
+	t.Ok(err)
+	t.Equals(StringAlias("2"), *ps)
+
+	var pt *Point32
+	err = elastic.Set(&pt, map[string]interface{}{"X": 1, "Y": "2"})
+	t.Ok(err)
+	t.Equals(&Point32{X: 1, Y: 2}, pt)
+
+	err = elastic.Set(&p, "XYZ")
+	t.MustFail(err, "Expected conversion to fail")
+
+	// pointer chains are allocated level by level
+	var pp **int
+	err = elastic.Set(&pp, "5")
+	t.Ok(err)
+	t.Equals(5, **pp)
+
+	err = elastic.Set(&pp, nil)
+	t.Ok(err)
+	t.Assert(pp == nil, "Expected pointer to be nil")
+
+	var ppp ***StringAlias
+	err = elastic.Set(&ppp, &f)
+	t.Ok(err)
+	t.Equals(StringAlias("2"), ***ppp)
+
+	var pps []**int
+	err = elastic.Set(&pps, []string{"1", "2"})
+	t.Ok(err)
+	t.Equals(2, **pps[1])
+}
+
+func TestUnsupportedKinds(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	ch := make(chan int)
+	f := func() {}
+	tests := []struct {
+		source     interface{}
+		targetType reflect.Type
+	}{
+		{ch, reflect.TypeOf("")},
+		{ch, reflect.TypeOf([]int{})},
+		{ch, reflect.TypeOf(make(chan string))},
+		{f, reflect.TypeOf(0)},
+		{f, reflect.TypeOf(func(int) {})},
+		{unsafe.Pointer(&f), reflect.TypeOf(uintptr(0))},
+		{"abc", reflect.TypeOf(ch)},
+		{[]int{1}, reflect.TypeOf(f)},
+		{map[string]interface{}{"a": ch}, reflect.TypeOf(map[string]string{})},
+	}
+	for _, test := range tests {
+		t.StartSubTest("Conversion of %T to %s", test.source, test.targetType)
+		_, err := elastic.Convert(test.source, test.targetType)
+		t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+	}
+
+	_, err := elastic.Convert(ch, reflect.TypeOf(""))
+	t.Equals(fmt.Sprintf("cannot convert %#v of type chan int to string: chan values are not supported", ch), err.Error())
+
+	// same types and interfaces are fine
+	t.Equals(reflect.ValueOf(ch).Pointer(), reflect.ValueOf(elastic.MustConvert(ch, reflect.TypeOf(ch))).Pointer())
+	var i interface{}
+	t.Ok(elastic.Set(&i, ch))
+	var m map[string]interface{}
+	t.Ok(elastic.Set(&m, struct{ C chan int }{ch}))
+}
+
+func TestSetValue(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	var p Point3D
+	V := reflect.ValueOf(&p).Elem()
+	t.Ok(elastic.SetValue(V.Field(0), "1.5"))
+	t.Ok(elastic.SetValue(V.Field(1), 2))
+	t.Ok(elastic.SetValue(V.Field(2), "3"))
+	t.Equals(Point3D{X: 1.5, Y: "2", Z: 3}, p)
+
+	err := elastic.SetValue(V.Field(3), 4)
+	t.MustFailWith(err, elastic.ErrNotSettable)
+
+	err = elastic.SetValue(reflect.ValueOf(p), Point3D{})
+	t.MustFailWith(err, elastic.ErrNotSettable)
+
+	err = elastic.SetValue(V.Field(2), "x")
+	t.MustFail(err, "Expected conversion to fail")
+	t.Equals(3, p.Z)
+}
+
+func TestInterfaceTarget(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	buffer := new(bytes.Buffer)
+	var w io.Writer
+	err := elastic.Set(&w, buffer)
+	t.Ok(err)
+	t.Assert(w == buffer, "Expected the source to be set as is")
+
+	now := time.Now()
+	var i interface{}
+	err = elastic.Set(&i, now)
+	t.Ok(err)
+	t.Equals(now, i)
+
+	err = elastic.Set(&i, Point3D{X: 1})
+	t.Ok(err)
+	t.Equals(Point3D{X: 1}, i)
+
+	var s fmt.Stringer
+	err = elastic.Set(&s, &TestStruct{X: 1, Y: 2})
+	t.Ok(err)
+	t.Equals("(1, 2)", s.String())
+
+	err = elastic.Set(&w, "abc")
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+}
+
+func TestMustConvert(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	t.Equals(42, elastic.MustConvert("42", reflect.TypeOf(0)))
+
+	var f float64
+	elastic.MustSet(&f, "1.5")
+	t.Equals(1.5, f)
+
+	mustPanic := func(f func()) {
+		defer func() {
+			t.Assert(recover() != nil, "Expected a panic")
+		}()
+		f()
+	}
+	mustPanic(func() { elastic.MustConvert("XYZ", reflect.TypeOf(0)) })
+	mustPanic(func() { elastic.MustSet(&f, "XYZ") })
+	mustPanic(func() { elastic.New().MustSet(f, 1) })
+}
+
+func TestRemoveConverters(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	engine := elastic.New()
+	always := func(result interface{}) elastic.ConverterFunc {
+		return func(source interface{}, targetType reflect.Type) (interface{}, error) {
+			return result, nil
+		}
+	}
+	stringType := reflect.TypeOf("")
+	intType := reflect.TypeOf(0)
+
+	engine.AddSourceConverter(stringType, always(1))
+	engine.AddTargetConverter(intType, always(2))
+	engine.AddInterfaceConverter(reflect.TypeOf((*fmt.Stringer)(nil)).Elem(), always(3))
+
+	t.Equals(1, engine.MustConvert("5", intType))
+	engine.RemoveSourceConverter(stringType)
+	t.Equals(2, engine.MustConvert("5", intType))
+	engine.RemoveTargetConverter(intType)
+	t.Equals(5, engine.MustConvert("5", intType))
+
+	t.Equals(3, engine.MustConvert(time.Second, intType))
+	engine.RemoveInterfaceConverter(reflect.TypeOf((*fmt.Stringer)(nil)).Elem())
+	t.Equals(1000000000, engine.MustConvert(time.Second, intType))
+
+	engine.AddSourceConverter(stringType, always(1))
+	engine.AddTargetConverter(intType, always(2))
+	engine.Reset()
+	t.Equals(5, engine.MustConvert("5", intType))
+}
+
+func TestAddConversion(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	stringType := reflect.TypeOf("")
+	intType := reflect.TypeOf(0)
+	engine := elastic.New()
+	engine.AddSourceConverter(stringType, func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		return 1, nil
+	})
+	engine.AddConversion(stringType, intType, func(source interface{}) (interface{}, error) {
+		return len(source.(string)), nil
+	})
+
+	t.Equals(3, engine.MustConvert("abc", intType))
+	t.Equals(1.0, engine.MustConvert("abc", reflect.TypeOf(0.0)))
+
+	engine.AddConversion(stringType, intType, func(source interface{}) (interface{}, error) {
+		return nil, elastic.ErrNoConversionAvailable
+	})
+	t.Equals(1, engine.MustConvert("abc", intType))
+
+	clone := engine.Clone()
+	engine.RemoveConversion(stringType, intType)
+	engine.RemoveSourceConverter(stringType)
+	t.Equals(5, engine.MustConvert("5", intType))
+	t.Equals(1, clone.MustConvert("5", intType))
+
+	clone.AddConversion(stringType, intType, func(source interface{}) (interface{}, error) {
+		return nil, ErrAny
+	})
+	_, err := clone.Convert("5", intType)
+	t.MustFailWith(err, ErrAny)
+	clone.Reset()
+	t.Equals(5, clone.MustConvert("5", intType))
+}
+
+type Packet struct {
+	ID      int
+	Payload string
+}
+
+func TestBinaryCodec(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	packetType := reflect.TypeOf(Packet{})
+	engine := elastic.New()
+	engine.AddBinaryCodec(packetType, func(v interface{}) ([]byte, error) {
+		var buf bytes.Buffer
+		err := gob.NewEncoder(&buf).Encode(v)
+		return buf.Bytes(), err
+	}, func(b []byte) (interface{}, error) {
+		var p Packet
+		err := gob.NewDecoder(bytes.NewReader(b)).Decode(&p)
+		return p, err
+	})
+
+	packet := Packet{ID: 7, Payload: "hello"}
+	b, err := engine.Convert(packet, reflect.TypeOf([]byte{}))
+	t.Ok(err)
+	t.Equals(packet, engine.MustConvert(b, packetType))
+
+	alias := engine.MustConvert(packet, reflect.TypeOf(BytesAlias{}))
+	t.Equals(packet, engine.MustConvert(alias, packetType))
+
+	// other conversions are unaffected
+	t.Equals(map[string]interface{}{"ID": 7, "Payload": "hello"}, engine.MustConvert(packet, reflect.TypeOf(map[string]interface{}{})))
+
+	_, err = engine.Convert([]byte{1, 2, 3}, packetType)
+	t.MustFail(err, "Expected decoding to fail")
+}
+
+func TestFallbackConverter(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	intType := reflect.TypeOf(0)
+	engine := elastic.New()
+	engine.SetFallbackConverter(func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		if p, ok := source.(Point3D); ok && targetType.Kind() == reflect.Int {
+			return p.Z, nil
+		}
+		return nil, elastic.ErrNoConversionAvailable
+	})
+
+	t.Equals(3, engine.MustConvert(Point3D{Z: 3}, intType))
+	t.Equals(5, engine.MustConvert("5", intType)) // regular conversions take precedence
+	_, err := engine.Convert(Point3D{}, reflect.TypeOf(0.0))
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+
+	clone := engine.Clone()
+	engine.SetFallbackConverter(nil)
+	_, err = engine.Convert(Point3D{Z: 3}, intType)
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+	t.Equals(3, clone.MustConvert(Point3D{Z: 3}, intType))
+
+	clone.SetFallbackConverter(func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		return nil, ErrAny
+	})
+	_, err = clone.Convert(Point3D{Z: 3}, intType)
+	t.MustFailWith(err, ErrAny)
+	clone.Reset()
+	_, err = clone.Convert(Point3D{Z: 3}, intType)
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+}
+
+func TestInterfaceConverterOrder(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	always := func(result interface{}) elastic.ConverterFunc {
+		return func(source interface{}, targetType reflect.Type) (interface{}, error) {
+			return result, nil
+		}
+	}
+	stringerType := reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	anyType := reflect.TypeOf((*interface{})(nil)).Elem()
+	intType := reflect.TypeOf(0)
+
+	// time.Duration matches both interfaces, so the first one registered wins
+	for i := 0; i < 20; i++ {
+		engine := elastic.New()
+		engine.AddInterfaceConverter(stringerType, always(1))
+		engine.AddInterfaceConverter(anyType, always(2))
+		t.Equals(1, engine.MustConvert(time.Second, intType))
+
+		engine = elastic.New()
+		engine.AddInterfaceConverter(anyType, always(2))
+		engine.AddInterfaceConverter(stringerType, always(1))
+		t.Equals(2, engine.MustConvert(time.Second, intType))
+	}
+}
+
+func TestClone(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	engine := elastic.New()
+	engine.RoundingMode = elastic.Round
+	engine.AddTargetConverter(reflect.TypeOf(""), func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		return nil, elastic.ErrNoConversionAvailable
+	})
+
+	clone := engine.Clone()
+	t.Equals(3, clone.MustConvert(2.5, reflect.TypeOf(0)))
+	clone.AddTargetConverter(reflect.TypeOf(""), func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		return "clone", nil
+	})
+	clone.RoundingMode = elastic.Floor
+
+	t.Equals("clone", clone.MustConvert(1, reflect.TypeOf("")))
+	t.Equals("1", engine.MustConvert(1, reflect.TypeOf("")))
+	t.Equals(2, clone.MustConvert(2.5, reflect.TypeOf(0)))
+	t.Equals(3, engine.MustConvert(2.5, reflect.TypeOf(0)))
+}
+
+func TestConverterCacheInvalidation(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	engine := elastic.New()
+	intType := reflect.TypeOf(0)
+	t.Equals(5, engine.MustConvert("5", intType))
+
+	// registering a converter after a conversion between the same types must take effect
+	engine.AddSourceConverter(reflect.TypeOf(""), func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		return 10, nil
+	})
+	t.Equals(10, engine.MustConvert("5", intType))
+
+	engine.AddInterfaceConverter(reflect.TypeOf((*fmt.Stringer)(nil)).Elem(), func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		return 20, nil
+	})
+	t.Equals(20, engine.MustConvert(time.Second, intType))
+}
+
+func BenchmarkConvertMap(b *testing.B) {
+	source := map[string]interface{}{
+		"1": "uno",
+		"2": "dos",
+		"3": "tres",
+	}
+	targetType := reflect.TypeOf(map[int]string{})
+	for i := 0; i < b.N; i++ {
+		elastic.Convert(source, targetType)
+	}
+}
+
+func TestConvertSliceAndMap(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	ints, err := elastic.ConvertSlice([]interface{}{"1", 2, 3.0}, reflect.TypeOf([]int{}))
+	t.Ok(err)
+	t.Equals([]int{1, 2, 3}, ints)
+
+	_, err = elastic.ConvertSlice("123", reflect.TypeOf([]int{}))
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+	_, err = elastic.ConvertSlice([]int{1}, reflect.TypeOf(0))
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+
+	m, err := elastic.ConvertMap(map[string]string{"1": "2"}, reflect.TypeOf(map[int]int{}))
+	t.Ok(err)
+	t.Equals(map[int]int{1: 2}, m)
+
+	_, err = elastic.ConvertMap(Point32{}, reflect.TypeOf(map[string]int{}))
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+	_, err = elastic.ConvertMap(nil, reflect.TypeOf(map[string]int{}))
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+
+	// converters registered for the slice or map types are honored, as in Convert
+	engine := elastic.New()
+	engine.AddConversion(reflect.TypeOf([]int{}), reflect.TypeOf([]string{}), func(source interface{}) (interface{}, error) {
+		return []string{"custom"}, nil
+	})
+	engine.AddConversion(reflect.TypeOf(map[string]int{}), reflect.TypeOf(map[string]string{}), func(source interface{}) (interface{}, error) {
+		return map[string]string{"custom": "yes"}, nil
+	})
+	strs, err := engine.ConvertSlice([]int{1}, reflect.TypeOf([]string{}))
+	t.Ok(err)
+	t.Equals([]string{"custom"}, strs)
+	m, err = engine.ConvertMap(map[string]int{"a": 1}, reflect.TypeOf(map[string]string{}))
+	t.Ok(err)
+	t.Equals(map[string]string{"custom": "yes"}, m)
+
+	// failures describe the value and the types involved, as in Convert
+	_, err = elastic.ConvertSlice("123", reflect.TypeOf([]int{}))
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError, got %v", err)
+	t.Equals("123", conversionError.Source)
+	t.Equals(reflect.TypeOf(""), conversionError.SourceType)
+	t.Equals(reflect.TypeOf([]int{}), conversionError.TargetType)
+
+	_, err = elastic.ConvertMap(Point32{}, reflect.TypeOf(map[string]int{}))
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError, got %v", err)
+	t.Equals(reflect.TypeOf(Point32{}), conversionError.SourceType)
+	t.Equals(reflect.TypeOf(map[string]int{}), conversionError.TargetType)
+}
+
+type Entry struct {
+	Key   string
+	Value int
+}
+
+func TestMapEntries(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	var entries []Entry
+	t.Ok(elastic.Set(&entries, map[string]string{"a": "1"}))
+	t.Equals([]Entry{{Key: "a", Value: 1}}, entries)
+
+	var pairs [][2]interface{}
+	t.Ok(elastic.Set(&pairs, map[int]bool{1: true}))
+	t.Equals([][2]interface{}{{1, true}}, pairs)
+
+	t.Ok(elastic.Set(&entries, map[string]int{"a": 1, "b": 2, "c": 3}))
+	t.Equals(3, len(entries))
+	m := make(map[string]int)
+	for _, entry := range entries {
+		m[entry.Key] = entry.Value
+	}
+	t.Equals(map[string]int{"a": 1, "b": 2, "c": 3}, m)
+
+	t.Ok(elastic.Set(&m, []Entry{{Key: "x", Value: 1}, {Key: "y", Value: 2}}))
+	t.Equals(map[string]int{"x": 1, "y": 2}, m)
+
+	var floats map[float64]string
+	t.Ok(elastic.Set(&floats, [][2]string{{"1.5", "a"}, {"2", "b"}}))
+	t.Equals(map[float64]string{1.5: "a", 2: "b"}, floats)
+
+	t.Ok(elastic.Set(&floats, []interface{}{[]interface{}{3, 4}}))
+	t.Equals(map[float64]string{3: "4"}, floats)
+
+	err := elastic.Set(&floats, [][2]string{{"1", "a"}, {"x", "b"}})
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("[1]", conversionError.Path)
+
+	err = elastic.Set(&floats, [][]int{{1, 2, 3}})
+	t.Assert(errors.Is(err, elastic.ErrLengthMismatch), "Expected length mismatch, got %v", err)
+
+	engine := elastic.New()
+	engine.OnError = elastic.UseZero
+	t.Ok(engine.Set(&m, []interface{}{Entry{Key: "a", Value: 1}, "b", []string{"c", "x"}}))
+	t.Equals(map[string]int{"a": 1, "c": 0}, m)
+}
+
+func TestSortMapEntries(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	engine := elastic.New()
+	engine.SortMapEntries = true
+
+	var entries []Entry
+	t.Ok(engine.Set(&entries, map[string]int{"c": 3, "a": 1, "b": 2, "aa": 4}))
+	t.Equals([]Entry{{"a", 1}, {"aa", 4}, {"b", 2}, {"c", 3}}, entries)
+
+	var pairs [][2]int
+	t.Ok(engine.Set(&pairs, map[int]int{10: 1, -1: 2, 2: 3}))
+	t.Equals([][2]int{{-1, 2}, {2, 3}, {10, 1}}, pairs)
+
+	t.Ok(engine.Set(&pairs, map[float64]int{2.5: 1, 0.5: 2, 10: 3}))
+	t.Equals([][2]int{{0, 2}, {2, 1}, {10, 3}}, pairs)
+
+	t.Ok(engine.Set(&pairs, map[uint8]int{200: 1, 3: 2}))
+	t.Equals([][2]int{{3, 2}, {200, 1}}, pairs)
+
+	source := map[int]string{}
+	for i := 0; i < 100; i++ {
+		source[i] = fmt.Sprint(i)
+	}
+	first := engine.MustConvert(source, reflect.TypeOf([]Entry{}))
+	for i := 0; i < 10; i++ {
+		t.Equals(first, engine.MustConvert(source, reflect.TypeOf([]Entry{})))
+	}
+}
+
+func TestConvertAll(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	intType := reflect.TypeOf(0)
+	results, errs := elastic.ConvertAll([]interface{}{"1", "x", 3, Point32{}}, intType)
+	t.Equals([]interface{}{1, nil, 3, nil}, results)
+	t.Equals(4, len(errs))
+	t.Ok(errs[0])
+	t.Ok(errs[2])
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(errs[1], &conversionError), "Expected a ConversionError")
+	t.Equals("[1]", conversionError.Path)
+	t.Assert(errors.Is(errs[3], elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", errs[3])
+
+	results, errs = elastic.ConvertAll([]interface{}{"1", 2.0}, intType)
+	t.Equals([]interface{}{1, 2}, results)
+	t.Assert(errs == nil, "Expected no errors")
+
+	results, errs = elastic.ConvertAll(nil, intType)
+	t.Equals(0, len(results))
+	t.Assert(errs == nil, "Expected no errors")
+}
+
+func TestTrace(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	var events []string
+	engine := elastic.New()
+	engine.Trace = func(event string, source interface{}, targetType reflect.Type) {
+		events = append(events, fmt.Sprintf("%s: %v -> %s", event, source, targetType))
+	}
+
+	engine.MustConvert([]string{"1"}, reflect.TypeOf([]int{}))
+	t.Equals([]string{"slice: [1] -> []int", "parse string: 1 -> int"}, events)
+
+	events = nil
+	engine.MustConvert(&TestStruct{X: 1, Y: 2}, reflect.TypeOf(""))
+	t.Equals([]string{"ConverterTo: (1, 2) -> string", "Stringer: (1, 2) -> string"}, events)
+
+	events = nil
+	_, err := engine.Convert(Point3D{}, reflect.TypeOf(0))
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+	t.Equals("incompatible: {0  0 0} -> int", events[len(events)-1])
+}
+
+func TestFloatFormat(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	engine := elastic.New()
+	engine.FloatFormat = 'f'
+	engine.FloatPrecision = 2
+
+	var s string
+	err := engine.Set(&s, 3.14159)
+	t.Ok(err)
+	t.Equals("3.14", s)
+
+	err = engine.Set(&s, complex(1, 0.5))
+	t.Ok(err)
+	t.Equals("(1.00+0.50i)", s)
+
+	engine.FloatFormat = 'e'
+	engine.FloatPrecision = -1
+	err = engine.Set(&s, 1500.0)
+	t.Ok(err)
+	t.Equals("1.5e+03", s)
+}
+
+func TestBoolStrings(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	engine := elastic.New()
+	engine.TrueStrings = []string{"sí"}
+	engine.FalseStrings = []string{"no"}
+
+	var b bool
+	err := engine.Set(&b, "SÍ")
+	t.Ok(err)
+	t.Equals(true, b)
+
+	err = engine.Set(&b, "No")
+	t.Ok(err)
+	t.Equals(false, b)
+
+	err = engine.Set(&b, "true")
+	t.MustFail(err, "Expected parsing to fail")
+
+	// booleans are formatted as the first true or false string
+	stringType := reflect.TypeOf("")
+	t.Equals("true", elastic.MustConvert(true, stringType))
+	t.Equals("false", elastic.MustConvert(false, stringType))
+	t.Equals("sí", engine.MustConvert(true, stringType))
+	t.Equals(StringAlias("no"), engine.MustConvert(false, reflect.TypeOf(StringAlias(""))))
+
+	engine.TrueStrings = []string{"1", "yes"}
+	engine.FalseStrings = nil
+	t.Equals("1", engine.MustConvert(true, stringType))
+	t.Equals("false", engine.MustConvert(false, stringType))
+}
+
+func TestBoolNumberOverride(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	// user-registered converters take precedence over the built-in numeric/bool conversion
+	engine := elastic.New()
+	engine.AddSourceConverter(reflect.TypeOf(true), func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		if source.(bool) {
+			return -1, nil
+		}
+		return 0, nil
+	})
+	t.Equals(-1, engine.MustConvert(true, reflect.TypeOf(0)))
+}
+
+func TestConvertWithDefault(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	intType := reflect.TypeOf(0)
+	t.Equals(5, elastic.ConvertWithDefault("5", intType, -1))
+	t.Equals(-1, elastic.ConvertWithDefault("abc", intType, -1))
+	t.Equals(-1, elastic.ConvertWithDefault("abc", intType, "-1"))
+	t.Equals(0, elastic.ConvertWithDefault("abc", intType, "def"))
+
+	var i int
+	err := elastic.SetWithDefault(&i, "5", -1)
+	t.Ok(err)
+	t.Equals(5, i)
+
+	err = elastic.SetWithDefault(&i, "abc", -1)
+	t.Ok(err)
+	t.Equals(-1, i)
+
+	err = elastic.SetWithDefault(&i, "abc", "def")
+	t.MustFail(err, "Expected default to fail")
+}
+
+func TestUnwrapSingleElementSlices(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	intType := reflect.TypeOf(0)
+	_, err := elastic.Convert([]string{"8080"}, intType)
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+
+	engine := elastic.New()
+	engine.UnwrapSingleElementSlices = true
+	t.Equals(8080, engine.MustConvert([]string{"8080"}, intType))
+	t.Equals(0, engine.MustConvert([]string{}, intType))
+	t.Equals("3", engine.MustConvert([1]int{3}, reflect.TypeOf("")))
+	t.Equals(true, engine.MustConvert([]interface{}{"yes"}, reflect.TypeOf(false)))
+
+	_, err = engine.Convert([]string{"1", "2"}, intType)
+	t.Assert(errors.Is(err, elastic.ErrLengthMismatch), "Expected length mismatch, got %v", err)
+
+	_, err = engine.Convert([]string{"x"}, intType)
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("[0]", conversionError.Path)
+
+	// byte slices still convert to strings as a whole
+	t.Equals("ab", engine.MustConvert([]byte("ab"), reflect.TypeOf("")))
+}
+
+func TestWrapScalarsIntoSlices(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	intsType := reflect.TypeOf([]int{})
+	_, err := elastic.Convert("8080", intsType)
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+
+	engine := elastic.New()
+	engine.WrapScalarsIntoSlices = true
+	t.Equals([]int{8080}, engine.MustConvert("8080", intsType))
+	t.Equals([]string{"5"}, engine.MustConvert(5, reflect.TypeOf([]string{})))
+	t.Equals([]interface{}{true}, engine.MustConvert(true, reflect.TypeOf([]interface{}{})))
+
+	// slices are converted element by element as usual
+	t.Equals([]int{1, 2}, engine.MustConvert([]string{"1", "2"}, intsType))
+
+	_, err = engine.Convert("x", intsType)
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("[0]", conversionError.Path)
+
+	// strings still convert to byte slices as a whole
+	t.Equals([]byte("ab"), engine.MustConvert("ab", reflect.TypeOf([]byte{})))
+}
+
+func TestDisableStringParsing(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	engine := elastic.New()
+	engine.DisableStringParsing = true
+
+	_, err := engine.Convert("5", reflect.TypeOf(0))
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+	_, err = engine.Convert("5", reflect.TypeOf(uint8(0)))
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+	_, err = engine.Convert("5.5", reflect.TypeOf(0.0))
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+	_, err = engine.Convert("true", reflect.TypeOf(false))
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+
+	// conversions to strings are unaffected
+	t.Equals(StringAlias("5"), engine.MustConvert("5", reflect.TypeOf(StringAlias(""))))
+	t.Equals("5", engine.MustConvert(5, reflect.TypeOf("")))
+	t.Equals("(1, 2)", engine.MustConvert(&TestStruct{X: 1, Y: 2}, reflect.TypeOf("")))
+}
+
+func TestCanConvert(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	type Node struct {
+		Value    int
+		Children []*Node
+	}
+
+	intType := reflect.TypeOf(0)
+	stringType := reflect.TypeOf("")
+	t.Assert(elastic.CanConvert(stringType, intType), "Expected strings to be convertible to ints")
+	t.Assert(elastic.CanConvert(reflect.TypeOf([]string{}), reflect.TypeOf([]float64{})), "Expected []string to be convertible to []float64")
+	t.Assert(elastic.CanConvert(reflect.TypeOf(map[string]interface{}{}), reflect.TypeOf(Node{})), "Expected maps to be convertible to structs")
+	t.Assert(elastic.CanConvert(reflect.TypeOf([]Node{}), reflect.TypeOf([]map[string]interface{}{})), "Expected recursive structs to be convertible to maps")
+	t.Assert(elastic.CanConvert(reflect.TypeOf(&Point32{}), reflect.TypeOf(Point3D{})), "Expected pointer sources to be dereferenced")
+	t.Assert(!elastic.CanConvert(reflect.TypeOf([]bool{}), intType), "Expected slices not to be convertible to ints")
+	t.Assert(!elastic.CanConvert(reflect.TypeOf(map[string]chan int{}), reflect.TypeOf(map[string]int{})), "Expected channel elements not to be convertible")
+
+	engine := elastic.New()
+	engine.DisableStringParsing = true
+	t.Assert(!engine.CanConvert(stringType, intType), "Expected string parsing to be disabled")
+
+	called := false
+	engine.AddSourceConverter(stringType, func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		called = true
+		return nil, elastic.ErrNoConversionAvailable
+	})
+	t.Assert(engine.CanConvert(stringType, intType), "Expected source converters to be assumed to convert")
+	t.Assert(!called, "Expected converters not to be run")
+}
+
+type Audit struct {
+	ID      int
+	Created string
+}
+
+type Owner struct {
+	Name string
+}
+
+type Document struct {
+	Audit
+	*Owner
+	ID    string
+	Title string
+	Meta  Point3D `elastic:"meta"`
+}
+
+func TestEmbeddedStructs(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	doc := Document{Audit: Audit{ID: 7, Created: "today"}, Owner: &Owner{Name: "Ann"}, ID: "doc-1", Title: "Report", Meta: Point3D{X: 1}}
+	var m map[string]interface{}
+	err := elastic.Set(&m, doc)
+	t.Ok(err)
+	t.Equals("doc-1", m["ID"]) // outer fields take precedence
+	t.Equals("today", m["Created"])
+	t.Equals("Ann", m["Name"])
+	t.Equals(map[string]interface{}{"X": 1.0, "Y": "", "Z": 0}, m["meta"]) // named fields still nest
+	_, nested := m["Audit"]
+	t.Assert(!nested, "Expected embedded structs to be flattened")
+
+	doc.Owner = nil
+	m = nil
+	err = elastic.Set(&m, doc)
+	t.Ok(err)
+	_, found := m["Name"]
+	t.Assert(!found, "Expected fields of nil embedded pointers to be left out")
+
+	var d Document
+	err = elastic.Set(&d, map[string]interface{}{"ID": "doc-2", "Created": "yesterday", "Name": "Bob", "meta": map[string]int{"Z": 3}})
+	t.Ok(err)
+	t.Equals("doc-2", d.ID)
+	t.Equals(0, d.Audit.ID)
+	t.Equals("yesterday", d.Created)
+	t.Equals("Bob", d.Owner.Name)
+	t.Equals(3, d.Meta.Z)
+
+	// fields promoted through nil embedded pointers are skipped when converting to other structs
+	type Summary struct {
+		Title string
+		Name  string
+	}
+	doc.Owner = nil
+	t.Equals(Summary{Title: "Report"}, elastic.MustConvert(doc, reflect.TypeOf(Summary{})))
+	summary := Summary{Name: "kept"}
+	t.Ok(elastic.ConvertInto(&summary, doc))
+	t.Equals(Summary{Title: "Report", Name: "kept"}, summary)
+}
+
+func TestFieldNameMapper(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	t.Equals("user_name", elastic.SnakeCase("UserName"))
+	t.Equals("user_id", elastic.SnakeCase("UserID"))
+	t.Equals("http_server", elastic.SnakeCase("HTTPServer"))
+	t.Equals("address2", elastic.SnakeCase("Address2"))
+
+	type Account struct {
+		UserName  string
+		AccountID int
+		Email     string `elastic:"mail"`
+	}
+
+	engine := elastic.New()
+	engine.SetFieldNameMapper(elastic.SnakeCase)
+
+	var account Account
+	err := engine.Set(&account, map[string]interface{}{"user_name": "ann", "account_id": "42", "mail": "ann@example.com", "Email": "ignored"})
+	t.Ok(err)
+	t.Equals(Account{UserName: "ann", AccountID: 42, Email: "ann@example.com"}, account)
+
+	var m map[string]interface{}
+	err = engine.Set(&m, account)
+	t.Ok(err)
+	t.Equals(map[string]interface{}{"user_name": "ann", "account_id": 42, "mail": "ann@example.com"}, m)
+
+	engine.SetFieldNameMapper(nil)
+	m = nil
+	err = engine.Set(&m, account)
+	t.Ok(err)
+	t.Equals("ann", m["UserName"])
+}
+
+func TestCaseInsensitiveFields(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	source := map[string]interface{}{"username": "ann", "AGE": 30, "password": "secret"}
+	var ts TaggedStruct
+	err := elastic.Set(&ts, source)
+	t.Ok(err)
+	t.Equals(TaggedStruct{}, ts)
+
+	engine := elastic.New()
+	engine.CaseInsensitiveFields = true
+	engine.TagKey = "json"
+	err = engine.Set(&ts, map[string]interface{}{"NAME": "ann", "AGE": 31, "age": 30, "password": "secret"})
+	t.Ok(err)
+	t.Equals(TaggedStruct{UserName: "ann", Age: 30}, ts) // exact matches are preferred
+}
+
+func TestUnknownFields(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	source := map[string]interface{}{"user_name": "ann", "Age": 30, "tiemout": 5, "Password": "secret"}
+	var ts TaggedStruct
+	t.Ok(elastic.Set(&ts, source))
+	t.Equals(TaggedStruct{UserName: "ann", Age: 30}, ts)
+
+	engine := elastic.New()
+	engine.ErrorOnUnknownFields = true
+	err := engine.Set(&ts, source)
+	t.Assert(errors.Is(err, elastic.ErrUnknownFields), "Expected ErrUnknownFields, got %v", err)
+	t.Equals("Unknown fields: Password, tiemout", err.Error()) // skipped fields are unknown too
+
+	ts = TaggedStruct{}
+	t.Ok(engine.Set(&ts, map[string]interface{}{"user_name": "ann", "Age": 30}))
+	t.Equals(TaggedStruct{UserName: "ann", Age: 30}, ts)
+
+	// keys matched case-insensitively are known
+	engine.CaseInsensitiveFields = true
+	t.Ok(engine.Set(&ts, map[string]interface{}{"USER_NAME": "bob", "age": 40}))
+	t.Equals(TaggedStruct{UserName: "bob", Age: 40}, ts)
+
+	// nested structs report the path to the map with unknown keys
+	var doc struct {
+		Owner TaggedStruct
+	}
+	err = engine.Set(&doc, map[string]interface{}{"Owner": map[string]interface{}{"user_name": "ann", "email": "a@b.c"}})
+	t.Assert(errors.Is(err, elastic.ErrUnknownFields), "Expected ErrUnknownFields, got %v", err)
+	t.Equals("Owner: Unknown fields: email", err.Error())
+}
+
+func TestMissingFields(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	type Config struct {
+		Host    string `elastic:"host,required"`
+		Port    int    `elastic:"port,required"`
+		Timeout int    `elastic:"timeout"`
+	}
+
+	var cfg Config
+	err := elastic.Set(&cfg, map[string]interface{}{"timeout": 5})
+	t.Assert(errors.Is(err, elastic.ErrMissingFields), "Expected ErrMissingFields, got %v", err)
+	t.Equals("Missing fields: host, port", err.Error())
+
+	t.Ok(elastic.Set(&cfg, map[string]interface{}{"host": "localhost", "port": "8080"}))
+	t.Equals(Config{Host: "localhost", Port: 8080}, cfg)
+
+	engine := elastic.New()
+	engine.ErrorOnMissingFields = true
+	err = engine.Set(&cfg, map[string]interface{}{"host": "localhost", "port": 80})
+	t.Assert(errors.Is(err, elastic.ErrMissingFields), "Expected ErrMissingFields, got %v", err)
+	t.Equals("Missing fields: timeout", err.Error())
+
+	// fields skipped by their tag are never required
+	var ts TaggedStruct
+	t.Ok(engine.Set(&ts, map[string]interface{}{"user_name": "ann", "Age": 30}))
+
+	// nested structs report the path to the map with missing keys
+	var servers []Config
+	err = elastic.Set(&servers, []map[string]interface{}{{"host": "a", "port": 1}, {"host": "b"}})
+	t.Assert(errors.Is(err, elastic.ErrMissingFields), "Expected ErrMissingFields, got %v", err)
+	t.Equals("[1]: Missing fields: port", err.Error())
+}
+
+func TestNonFinite(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	int8Type := reflect.TypeOf(int8(0))
+	uint16Type := reflect.TypeOf(uint16(0))
+	nan, inf := math.NaN(), math.Inf(1)
+
+	for _, f := range []float64{nan, inf, -inf} {
+		_, err := elastic.Convert(f, int8Type)
+		t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected ErrOverflow, got %v", err)
+	}
+	_, err := elastic.Convert("NaN", reflect.TypeOf(0))
+	t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected ErrOverflow, got %v", err)
+
+	engine := elastic.New()
+	engine.NonFinite = elastic.NonFiniteZero
+	t.Equals(int8(0), engine.MustConvert(inf, int8Type))
+	t.Equals(uint16(0), engine.MustConvert(nan, uint16Type))
+
+	engine.NonFinite = elastic.NonFiniteClamp
+	t.Equals(int8(127), engine.MustConvert(inf, int8Type))
+	t.Equals(int8(-128), engine.MustConvert(-inf, int8Type))
+	t.Equals(int8(0), engine.MustConvert(nan, int8Type))
+	t.Equals(uint16(65535), engine.MustConvert(inf, uint16Type))
+	t.Equals(uint16(0), engine.MustConvert(-inf, uint16Type))
+	t.Equals(uint64(math.MaxUint64), engine.MustConvert(inf, reflect.TypeOf(uint64(0))))
+	t.Equals(math.MinInt64, engine.MustConvert("-Inf", reflect.TypeOf(0)))
+
+	// finite out of range values still overflow
+	_, err = engine.Convert(1000.0, int8Type)
+	t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected ErrOverflow, got %v", err)
+
+	// non-finite floats format as strings that parse back
+	t.Equals("+Inf", elastic.MustConvert(inf, reflect.TypeOf("")))
+	t.Equals(inf, elastic.MustConvert("+Inf", reflect.TypeOf(0.0)))
+}
+
+func TestInterfaceElements(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	interfacesType := reflect.TypeOf([]interface{}{})
+	t.Equals([]interface{}{1, 2, 3}, elastic.MustConvert([]int{1, 2, 3}, interfacesType))
+	t.Equals([]interface{}{Point3D{X: 1}}, elastic.MustConvert([1]Point3D{{X: 1}}, interfacesType))
+	t.Equals(map[string]interface{}{"a": 1.5}, elastic.MustConvert(map[string]float64{"a": 1.5}, reflect.TypeOf(map[string]interface{}{})))
+
+	// converters of the source type are not consulted to box values
+	engine := elastic.New()
+	engine.AddSourceConverter(reflect.TypeOf(IntAlias(0)), func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		return nil, fmt.Errorf("cannot convert to %s", targetType)
+	})
+	t.Equals([]interface{}{IntAlias(1)}, engine.MustConvert([]IntAlias{1}, interfacesType))
+
+	// unless a conversion to the interface is registered explicitly
+	engine.RemoveSourceConverter(reflect.TypeOf(IntAlias(0)))
+	engine.AddTargetConverter(reflect.TypeOf((*interface{})(nil)).Elem(), func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		if _, ok := source.(string); ok {
+			return nil, elastic.ErrNoConversionAvailable
+		}
+		return fmt.Sprint(source), nil
+	})
+	t.Equals([]interface{}{"1"}, engine.MustConvert([]IntAlias{1}, interfacesType))
+}
+
+func TestNumberSeparators(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	floatType := reflect.TypeOf(0.0)
+	intType := reflect.TypeOf(0)
+
+	_, err := elastic.Convert("1,234.5", floatType)
+	t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+
+	engine := elastic.New()
+	engine.DecimalSeparator = ','
+	engine.ThousandsSeparator = '.'
+	t.Equals(1234.56, engine.MustConvert("1.234,56", floatType))
+	t.Equals(-1234567.0, engine.MustConvert("-1.234.567", floatType))
+	t.Equals(0.5, engine.MustConvert("0,5", floatType))
+	t.Equals(1234, engine.MustConvert("1.234", intType))
+	t.Equals(uint8(12), engine.MustConvert("12,3", reflect.TypeOf(uint8(0))))
+
+	for _, s := range []string{"1.23,4", "12.34", "1..234", ".234", "1,2,3"} {
+		_, err = engine.Convert(s, floatType)
+		t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse for %q, got %v", s, err)
+	}
+
+	engine = elastic.New()
+	engine.ThousandsSeparator = ','
+	t.Equals(1234567.89, engine.MustConvert("1,234,567.89", floatType))
+	t.Equals(int64(1000), engine.MustConvert("1,000", reflect.TypeOf(int64(0))))
+	_, err = engine.Convert("1,0000", intType)
+	t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+
+	engine = elastic.New()
+	engine.DecimalSeparator = ','
+	t.Equals(2.5, engine.MustConvert("2,5", floatType))
+	_, err = engine.Convert("2.5", floatType)
+	t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+}
+
+func TestPointerElements(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	result, err := elastic.Convert([]interface{}{"1", nil, 3.0, (*int)(nil)}, reflect.TypeOf([]*int{}))
+	t.Ok(err)
+	ints := result.([]*int)
+	t.Equals(4, len(ints))
+	t.Equals(1, *ints[0])
+	t.Assert(ints[1] == nil, "Expected nil sources to produce nil entries")
+	t.Equals(3, *ints[2])
+	t.Assert(ints[3] == nil, "Expected nil pointers to produce nil entries")
+
+	var structs map[string]*TestStruct
+	err = elastic.Set(&structs, map[string]interface{}{
+		"a": map[string]interface{}{"X": "1", "Y": 2},
+		"b": Point32{X: 3, Y: 4},
+		"c": nil,
+	})
+	t.Ok(err)
+	t.Equals(3, len(structs))
+	t.Equals(TestStruct{X: 1, Y: 2}, *structs["a"])
+	t.Equals(TestStruct{X: 3, Y: 4}, *structs["b"])
+	t.Assert(structs["c"] == nil, "Expected nil values to produce nil entries")
+
+	// and back, dereferencing the elements
+	t.Equals([]string{"1", "", "3", ""}, elastic.MustConvert(ints, reflect.TypeOf([]string{})))
+}
+
+func TestConvertLike(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	result, err := elastic.ConvertLike("5", int(0))
+	t.Ok(err)
+	t.Equals(5, result)
+
+	result, err = elastic.ConvertLike([]string{"1.5"}, []float32(nil))
+	t.Ok(err)
+	t.Equals([]float32{1.5}, result)
+
+	_, err = elastic.ConvertLike("x", 0)
+	t.MustFail(err, "Expected parsing to fail")
+
+	_, err = elastic.ConvertLike("5", nil)
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+}
+
+// celsius does not implement fmt.Stringer, so it needs an adapter to be converted to one
+type celsius float64
+
+type celsiusStringer struct {
+	c celsius
+}
+
+func (cs celsiusStringer) String() string {
+	return fmt.Sprintf("%.1f°C", float64(cs.c))
+}
+
+func TestAdapter(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	celsiusType := reflect.TypeOf(celsius(0))
+	stringerType := reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+	engine := elastic.New()
+	_, err := engine.Convert(celsius(21.5), stringerType)
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+
+	engine.AddAdapter(celsiusType, stringerType, func(source interface{}) (interface{}, error) {
+		return celsiusStringer{source.(celsius)}, nil
+	})
+	var s fmt.Stringer
+	t.Ok(engine.Set(&s, celsius(21.5)))
+	t.Equals("21.5°C", s.String())
+
+	var stringers []fmt.Stringer
+	t.Ok(engine.Set(&stringers, []interface{}{celsius(1), &TestStruct{X: 1, Y: 2}}))
+	t.Equals("1.0°C", stringers[0].String())
+	t.Equals("(1, 2)", stringers[1].String())
+
+	engine.AddAdapter(celsiusType, reflect.TypeOf((*io.Reader)(nil)).Elem(), func(source interface{}) (interface{}, error) {
+		return source, nil
+	})
+	var r io.Reader
+	err = engine.Set(&r, celsius(1))
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+
+	engine.RemoveConversion(celsiusType, stringerType)
+	_, err = engine.Convert(celsius(21.5), stringerType)
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+}
+
+func TestArraySources(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	array := [3]int{1, 2, 3}
+	result, err := elastic.ConvertSlice(array, reflect.TypeOf([]string{}))
+	t.Ok(err)
+	t.Equals([]string{"1", "2", "3"}, result)
+
+	t.Equals([]string{"1", "2", "3"}, elastic.MustConvert(&array, reflect.TypeOf([]string{})))
+
+	buffer := make([]string, 0, 5)
+	t.Ok(elastic.ConvertInto(&buffer, array))
+	t.Equals([]string{"1", "2", "3"}, buffer)
+	t.Equals(5, cap(buffer))
+}
+
+func TestOmitEmpty(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	type Patch struct {
+		Name    string   `elastic:",omitempty"`
+		Age     *int     `elastic:"age,omitempty"`
+		Tags    []string `elastic:",omitempty"`
+		Enabled bool
+	}
+
+	var m map[string]interface{}
+	t.Ok(elastic.Set(&m, Patch{Tags: []string{}}))
+	t.Equals(map[string]interface{}{"Enabled": false}, m)
+
+	zero := 0
+	m = nil
+	t.Ok(elastic.Set(&m, Patch{Name: "ann", Age: &zero}))
+	t.Equals(&zero, m["age"]) // non-nil pointers are kept
+
+	engine := elastic.New()
+	engine.TagKey = "json"
+	m = nil
+	t.Ok(engine.Set(&m, TaggedStruct{UserName: "ann"}))
+	t.Equals(map[string]interface{}{"name": "ann"}, m)
+
+	engine = elastic.New()
+	engine.OmitEmpty = true
+	m = nil
+	t.Ok(engine.Set(&m, Patch{}))
+	t.Equals(map[string]interface{}{}, m)
+}
+
+func TestConvertStream(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	var results []int
+	err := elastic.ConvertStream([]string{"1", "2", "3"}, reflect.TypeOf(0), func(i int, v interface{}) error {
+		t.Equals(len(results), i)
+		results = append(results, v.(int))
+		return nil
+	})
+	t.Ok(err)
+	t.Equals([]int{1, 2, 3}, results)
+
+	stop := errors.New("stop")
+	count := 0
+	err = elastic.ConvertStream([4]float64{1, 2, 3, 4}, reflect.TypeOf(""), func(i int, v interface{}) error {
+		count++
+		if i == 1 {
+			return stop
+		}
+		return nil
+	})
+	t.MustFailWith(err, stop)
+	t.Equals(2, count)
+
+	err = elastic.ConvertStream([]string{"1", "x"}, reflect.TypeOf(0), func(i int, v interface{}) error {
+		return nil
+	})
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("[1]", conversionError.Path)
+
+	err = elastic.ConvertStream("abc", reflect.TypeOf(0), func(i int, v interface{}) error {
+		return nil
+	})
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError, got %v", err)
+	t.Equals("abc", conversionError.Source)
+	t.Equals(reflect.TypeOf([]int{}), conversionError.TargetType)
+}
+
+func TestKindConverter(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	stringType := reflect.TypeOf("")
+	engine := elastic.New()
+	engine.AddKindConverter(reflect.Float64, func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		if targetType.Kind() != reflect.String {
+			return nil, elastic.ErrNoConversionAvailable
+		}
+		return fmt.Sprintf("%.2f", reflect.ValueOf(source).Float()), nil
+	})
+	t.Equals("1.50", engine.MustConvert(1.5, stringType))
+	t.Equals("2.00", engine.MustConvert(FloatAlias(2), stringType))
+	t.Equals(1, engine.MustConvert(1.5, reflect.TypeOf(0)))
+	t.Equals("1.5", engine.MustConvert(float32(1.5), stringType))
+
+	// converters for the exact type take precedence
+	engine.AddSourceConverter(reflect.TypeOf(FloatAlias(0)), func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		return "alias", nil
+	})
+	t.Equals("alias", engine.MustConvert(FloatAlias(2), stringType))
+
+	clone := engine.Clone()
+	engine.RemoveKindConverter(reflect.Float64)
+	t.Equals("1.5", engine.MustConvert(1.5, stringType))
+	t.Equals("1.50", clone.MustConvert(1.5, stringType))
+}
+
+func TestSplitStrings(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	intsType := reflect.TypeOf([]int{})
+	_, err := elastic.Convert("1,2,3", intsType)
+	t.MustFail(err, "Expected strings not to be split by default")
+
+	engine := elastic.New()
+	engine.SplitStrings = true
+	t.Equals([]int{1, 2, 3}, engine.MustConvert("1, 2 ,3", intsType))
+	t.Equals([]string{"a", "b", "c"}, engine.MustConvert("a,b,c", reflect.TypeOf([]string{})))
+	t.Equals([]string{"a"}, engine.MustConvert("a", reflect.TypeOf([]string{})))
+	t.Equals([2]float64{1.5, 2}, engine.MustConvert("1.5,2", reflect.TypeOf([2]float64{})))
+	empty := engine.MustConvert("", intsType).([]int)
+	t.Assert(empty != nil && len(empty) == 0, "Expected an empty, non-nil slice")
+
+	t.Assert(!elastic.CanConvert(reflect.TypeOf(""), intsType), "Expected strings not to convert to slices by default")
+	t.Assert(engine.CanConvert(reflect.TypeOf(""), intsType), "Expected strings to convert to slices when split")
+	t.Assert(engine.CanConvert(reflect.TypeOf(""), reflect.TypeOf([2]float64{})), "Expected strings to convert to arrays when split")
+	t.Assert(!engine.CanConvert(reflect.TypeOf(""), reflect.TypeOf([]chan int{})), "Expected pieces to be checked against the element type")
+
+	// byte and rune slices are not split
+	t.Equals([]byte("a,b"), engine.MustConvert("a,b", reflect.TypeOf([]byte{})))
+	t.Equals([]rune("a,b"), engine.MustConvert("a,b", reflect.TypeOf([]rune{})))
+
+	_, err = engine.Convert("1,x", intsType)
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("[1]", conversionError.Path)
+
+	engine.ListSeparator = ";"
+	var config struct {
+		Hosts []string
+		Ports []uint16
+	}
+	t.Ok(engine.Set(&config, map[string]string{"Hosts": "a.com; b.com", "Ports": "80;443"}))
+	t.Equals([]string{"a.com", "b.com"}, config.Hosts)
+	t.Equals([]uint16{80, 443}, config.Ports)
+}
+
+func TestJoinSlices(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	stringType := reflect.TypeOf("")
+	_, err := elastic.Convert([]int{1, 2, 3}, stringType)
+	t.MustFail(err, "Expected slices not to be joined by default")
+
+	engine := elastic.New()
+	engine.JoinSlices = true
+	t.Equals("1,2,3", engine.MustConvert([]int{1, 2, 3}, stringType))
+	t.Equals(StringAlias("1.5,true"), engine.MustConvert([2]interface{}{1.5, true}, reflect.TypeOf(StringAlias(""))))
+	t.Equals("", engine.MustConvert([]string{}, stringType))
+
+	// byte and rune slices are not joined
+	t.Equals("ab", engine.MustConvert([]byte("ab"), stringType))
+	t.Equals("ab", engine.MustConvert([]rune("ab"), stringType))
+	t.Equals("ab", engine.MustConvert([]int32{'a', 'b'}, stringType)) // []int32 is []rune
+
+	t.Assert(!elastic.CanConvert(reflect.TypeOf([]int{}), stringType), "Expected slices not to convert to strings by default")
+	t.Assert(engine.CanConvert(reflect.TypeOf([]int{}), stringType), "Expected slices to convert to strings when joined")
+	t.Assert(engine.CanConvert(reflect.TypeOf([3]float64{}), stringType), "Expected arrays to convert to strings when joined")
+	t.Assert(!engine.CanConvert(reflect.TypeOf([]chan int{}), stringType), "Expected elements to be checked against strings")
+
+	// elements are converted by the engine, honoring custom converters
+	engine.AddSourceConverter(reflect.TypeOf(true), func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		if source.(bool) {
+			return "on", nil
+		}
+		return "off", nil
+	})
+	engine.ListSeparator = "|"
+	t.Equals("on|off", engine.MustConvert([]bool{true, false}, stringType))
+
+	// and round trip with SplitStrings
+	engine.SplitStrings = true
+	t.Equals([]bool{true, false}, engine.MustConvert(engine.MustConvert([]bool{true, false}, stringType), reflect.TypeOf([]bool{})))
+
+	_, err = engine.Convert([]interface{}{1, Point3D{}}, stringType)
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("[1]", conversionError.Path)
+}
+
+func TestUintptr(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	uintptrType := reflect.TypeOf(uintptr(0))
+	t.Equals(uintptr(4096), elastic.MustConvert("4096", uintptrType))
+	t.Equals(uintptr(255), elastic.MustConvert("0xff", uintptrType))
+	t.Equals(uintptr(3), elastic.MustConvert(3.7, uintptrType))
+	t.Equals("4096", elastic.MustConvert(uintptr(4096), reflect.TypeOf("")))
+	t.Equals(int64(4096), elastic.MustConvert(uintptr(4096), reflect.TypeOf(int64(0))))
+	t.Equals(true, elastic.MustConvert(uintptr(1), reflect.TypeOf(false)))
+
+	for _, source := range []interface{}{-1, "-1", "99999999999999999999"} {
+		_, err := elastic.Convert(source, uintptrType)
+		t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected ErrOverflow for %v, got %v", source, err)
+	}
+	_, err := elastic.Convert("abc", uintptrType)
+	t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+}
+
+type Date struct {
+	Year, Month, Day int
+}
+
+func TestDateConversion(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	dateType := reflect.TypeOf(Date{})
+	timeType := reflect.TypeOf(time.Time{})
+
+	// without conversions, time.Time and Date don't share any field
+	t.Equals(Date{}, elastic.MustConvert(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), dateType))
+
+	engine := elastic.New()
+	engine.AddConversion(dateType, timeType, func(source interface{}) (interface{}, error) {
+		d := source.(Date)
+		return time.Date(d.Year, time.Month(d.Month), d.Day, 0, 0, 0, 0, time.UTC), nil
+	})
+	engine.AddConversion(timeType, dateType, func(source interface{}) (interface{}, error) {
+		year, month, day := source.(time.Time).Date()
+		return Date{Year: year, Month: int(month), Day: day}, nil
+	})
+
+	leapDay := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+	t.Equals(Date{2024, 2, 29}, engine.MustConvert(leapDay, dateType))
+	t.Equals(leapDay, engine.MustConvert(Date{2024, 2, 29}, timeType))
+
+	// the conversions also apply to nested fields and collection elements
+	var event struct {
+		Name string
+		When Date
+	}
+	t.Ok(engine.Set(&event, map[string]interface{}{"Name": "launch", "When": leapDay.Add(10 * time.Hour)}))
+	t.Equals(Date{2024, 2, 29}, event.When)
+
+	var dates []time.Time
+	t.Ok(engine.Set(&dates, []Date{{2024, 1, 1}, {2024, 12, 31}}))
+	t.Equals(time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), dates[1])
+
+	// maps still convert to the struct field by field
+	t.Equals(Date{2024, 3, 1}, engine.MustConvert(map[string]int{"Year": 2024, "Month": 3, "Day": 1}, dateType))
+}
+
+type Fahrenheit = celsius // aliases are the same type as the type they alias
+
+func TestBoxedSources(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	engine := elastic.New()
+	engine.AddSourceConverter(reflect.TypeOf(celsius(0)), func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		if targetType.Kind() != reflect.String {
+			return nil, elastic.ErrNoConversionAvailable
+		}
+		return fmt.Sprintf("%.1f°C", float64(source.(celsius))), nil
+	})
+	stringType := reflect.TypeOf("")
+
+	// converters are looked up by the dynamic type of the source, however it is boxed
+	var boxed interface{} = celsius(21.5)
+	t.Equals("21.5°C", engine.MustConvert(boxed, stringType))
+	t.Equals("21.5°C", engine.MustConvert(&boxed, stringType))
+	t.Equals("21.5°C", engine.MustConvert(Fahrenheit(21.5), stringType))
+	t.Equals([]string{"21.5°C", "3"}, engine.MustConvert([]interface{}{boxed, 3}, reflect.TypeOf([]string{})))
+	t.Equals(map[string]string{"room": "21.5°C"}, engine.MustConvert(map[string]interface{}{"room": boxed}, reflect.TypeOf(map[string]string{})))
+
+	var reading struct {
+		Temperature string
+	}
+	t.Ok(engine.Set(&reading, map[string]interface{}{"Temperature": boxed}))
+	t.Equals("21.5°C", reading.Temperature)
+
+	// the converter doesn't apply to the underlying type
+	t.Equals("21.5", engine.MustConvert(21.5, stringType))
+}
+
+func TestPointerToInterface(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	stringerPtrType := reflect.TypeOf((*fmt.Stringer)(nil))
+
+	// the pointer is allocated and set to the source boxed in the interface
+	result, err := elastic.Convert(celsiusStringer{c: 21.5}, stringerPtrType)
+	t.Ok(err)
+	stringer := result.(*fmt.Stringer)
+	t.Equals(celsiusStringer{c: 21.5}, *stringer)
+	t.Equals("21.5°C", (*stringer).String())
+
+	reader := strings.NewReader("hello")
+	result, err = elastic.Convert(reader, reflect.TypeOf((*io.Reader)(nil)))
+	t.Ok(err)
+	t.Assert(*result.(*io.Reader) == io.Reader(reader), "Expected the same reader to be boxed")
+
+	// sources that don't implement the interface fail
+	_, err = elastic.Convert(celsius(21.5), stringerPtrType)
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+
+	// unless an adapter makes them implement it
+	engine := elastic.New()
+	engine.AddAdapter(reflect.TypeOf(celsius(0)), stringerPtrType.Elem(), func(source interface{}) (interface{}, error) {
+		return celsiusStringer{c: source.(celsius)}, nil
+	})
+	result, err = engine.Convert(celsius(21.5), stringerPtrType)
+	t.Ok(err)
+	t.Equals("21.5°C", (*result.(*fmt.Stringer)).String())
+
+	// nil sources produce nil pointers
+	result, err = elastic.Convert(nil, stringerPtrType)
+	t.Ok(err)
+	t.Assert(result.(*fmt.Stringer) == nil, "Expected a nil pointer")
+
+	// pointers to interfaces work as fields and elements too
+	var target struct {
+		Input  *io.Reader
+		Labels []*fmt.Stringer
+	}
+	t.Ok(elastic.Set(&target, map[string]interface{}{
+		"Input":  reader,
+		"Labels": []interface{}{celsiusStringer{c: 1}, nil},
+	}))
+	t.Assert(*target.Input == io.Reader(reader), "Expected the reader to be set")
+	t.Equals(2, len(target.Labels))
+	t.Equals("1.0°C", (*target.Labels[0]).String())
+	t.Assert(target.Labels[1] == nil, "Expected a nil element")
+	t.Assert(elastic.CanConvert(reflect.TypeOf(reader), reflect.TypeOf((*io.Reader)(nil))), "Expected CanConvert to be true")
 }
@@ -0,0 +1,113 @@
+package elastic_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+// Decimal is a minimal stand-in for a third-party decimal type such as shopspring/decimal.Decimal
+type Decimal struct {
+	coefficient int64
+	exponent    int32 // the value is coefficient * 10^exponent
+}
+
+func NewDecimalFromString(s string) (Decimal, error) {
+	integer, fraction, _ := strings.Cut(s, ".")
+	coefficient, err := strconv.ParseInt(integer+fraction, 10, 64)
+	if err != nil {
+		return Decimal{}, err
+	}
+	return Decimal{coefficient: coefficient, exponent: -int32(len(fraction))}, nil
+}
+
+func (d Decimal) String() string {
+	s := strconv.FormatInt(d.coefficient, 10)
+	if d.exponent == 0 {
+		return s
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	for len(s) <= int(-d.exponent) {
+		s = "0" + s
+	}
+	point := len(s) + int(d.exponent)
+	return sign + s[:point] + "." + s[point:]
+}
+
+func (d Decimal) InexactFloat64() float64 {
+	return float64(d.coefficient) / math.Pow10(-int(d.exponent))
+}
+
+// registerDecimal registers the converters for Decimal the way a third-party decimal type would be registered
+func registerDecimal(engine *elastic.ConverterEngine) {
+	decimalType := reflect.TypeOf(Decimal{})
+	engine.AddSourceConverter(decimalType, func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		d := source.(Decimal)
+		switch targetType.Kind() {
+		case reflect.String:
+			return d.String(), nil
+		case reflect.Float32, reflect.Float64,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return d.InexactFloat64(), nil // the engine converts the float further, honoring its rounding options
+		}
+		return nil, elastic.ErrNoConversionAvailable
+	})
+	engine.AddTargetConverter(decimalType, func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		switch s := source.(type) {
+		case string:
+			return NewDecimalFromString(s)
+		case float64:
+			return NewDecimalFromString(strconv.FormatFloat(s, 'f', -1, 64))
+		}
+		// convert anything else, such as ints or float32, through a string
+		s, err := engine.Convert(source, reflect.TypeOf(""))
+		if err != nil {
+			return nil, elastic.ErrNoConversionAvailable
+		}
+		return NewDecimalFromString(s.(string))
+	})
+}
+
+func TestDecimal(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	engine := elastic.New()
+	registerDecimal(engine)
+	decimalType := reflect.TypeOf(Decimal{})
+
+	price := engine.MustConvert("19.99", decimalType).(Decimal)
+	t.Equals("19.99", price.String())
+	t.Equals("19.99", engine.MustConvert(price, reflect.TypeOf("")))
+	t.Equals(19.99, engine.MustConvert(price, reflect.TypeOf(0.0)))
+	t.Equals("0.05", engine.MustConvert(0.05, decimalType).(Decimal).String())
+	t.Equals("-3", engine.MustConvert(-3, decimalType).(Decimal).String())
+
+	var order struct {
+		Total    Decimal
+		Quantity int
+	}
+	t.Ok(engine.Set(&order, map[string]interface{}{"Total": "2.5", "Quantity": "2"}))
+	t.Equals("2.5", order.Total.String())
+
+	// conversions to integers go through floats, so they honor the rounding options
+	t.Equals(2, engine.MustConvert(order.Total, reflect.TypeOf(0)))
+	engine.RoundingMode = elastic.Round
+	t.Equals(3, engine.MustConvert(order.Total, reflect.TypeOf(0)))
+	engine.Strict = true
+	_, err := engine.Convert(order.Total, reflect.TypeOf(0))
+	t.Assert(errors.Is(err, elastic.ErrPrecisionLoss), "Expected ErrPrecisionLoss, got %v", err)
+
+	_, err = engine.Convert("abc", decimalType)
+	t.MustFail(err, "Expected parsing to fail")
+}
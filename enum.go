@@ -0,0 +1,48 @@
+package elastic
+
+import (
+	"reflect"
+)
+
+// AddEnum registers a bidirectional mapping between the names and values of an enum type,
+// so that the enum converts to its name when the target is a string and is parsed from its name
+// when the source is a string. The enum type must be of integer kind
+func (ce *ConverterEngine) AddEnum(enumType reflect.Type, names map[string]int) {
+	if !isInt(enumType.Kind()) && !isUint(enumType.Kind()) {
+		panic("enum type must be an integer")
+	}
+	values := make(map[string]int, len(names))
+	labels := make(map[int]string, len(names))
+	for name, value := range names {
+		values[name] = value
+		labels[value] = name
+	}
+
+	ce.AddSourceConverter(enumType, func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		if targetType.Kind() != reflect.String {
+			return nil, ErrNoConversionAvailable
+		}
+		S := reflect.ValueOf(source)
+		var value int
+		if isInt(S.Kind()) {
+			value = int(S.Int())
+		} else {
+			value = int(S.Uint())
+		}
+		if name, ok := labels[value]; ok {
+			return name, nil
+		}
+		return nil, ErrNoConversionAvailable
+	})
+
+	ce.AddTargetConverter(enumType, func(source interface{}, targetType reflect.Type) (interface{}, error) {
+		S := reflect.ValueOf(source)
+		if S.Kind() != reflect.String {
+			return nil, ErrNoConversionAvailable
+		}
+		if value, ok := values[S.String()]; ok {
+			return value, nil
+		}
+		return nil, ErrNoConversionAvailable
+	})
+}
@@ -0,0 +1,71 @@
+package elastic_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+	Blue
+)
+
+var colorNames = map[string]int{
+	"red":   int(Red),
+	"green": int(Green),
+	"blue":  int(Blue),
+}
+
+func TestEnum(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	engine := elastic.New()
+	engine.AddEnum(reflect.TypeOf(Red), colorNames)
+
+	var c Color
+	err := engine.Set(&c, "blue")
+	t.Ok(err)
+	t.Equals(Blue, c)
+
+	err = engine.Set(&c, StringAlias("green"))
+	t.Ok(err)
+	t.Equals(Green, c)
+
+	err = engine.Set(&c, "2")
+	t.Ok(err)
+	t.Equals(Blue, c)
+
+	err = engine.Set(&c, "purple")
+	t.MustFail(err, "Expected parsing to fail")
+
+	var s string
+	err = engine.Set(&s, Green)
+	t.Ok(err)
+	t.Equals("green", s)
+
+	err = engine.Set(&s, Color(7))
+	t.Ok(err)
+	t.Equals("7", s)
+
+	var colors []Color
+	err = engine.Set(&colors, []string{"red", "blue"})
+	t.Ok(err)
+	t.Equals([]Color{Red, Blue}, colors)
+
+	var i int
+	err = engine.Set(&i, Blue)
+	t.Ok(err)
+	t.Equals(2, i)
+
+	defer func() {
+		t.Assert(recover() != nil, "Expected AddEnum to panic with a non-integer type")
+	}()
+	engine.AddEnum(reflect.TypeOf(""), colorNames)
+}
@@ -0,0 +1,119 @@
+package elastic
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrParse is returned when a string can't be parsed into the target type because it has the wrong format.
+// The underlying parsing error, such as a *strconv.NumError, can still be retrieved with errors.Unwrap or errors.As
+var ErrParse = errors.New("Parse error")
+
+// parseFailure wraps a parsing error so that it matches ErrParse while unwrapping to the original error
+type parseFailure struct {
+	err error
+}
+
+// Error returns the error message of the underlying parsing error, prefixed by ErrParse's
+func (e *parseFailure) Error() string {
+	return fmt.Sprintf("%v: %v", ErrParse, e.err)
+}
+
+// Unwrap returns the underlying parsing error
+func (e *parseFailure) Unwrap() error {
+	return e.err
+}
+
+// Is makes parseFailure match ErrParse
+func (e *parseFailure) Is(target error) bool {
+	return target == ErrParse
+}
+
+// unsupportedKindError reports values of a kind that can't be converted, such as channels, matching ErrIncompatibleType
+type unsupportedKindError struct {
+	kind reflect.Kind
+}
+
+// Error returns the error message naming the unsupported kind
+func (e *unsupportedKindError) Error() string {
+	return fmt.Sprintf("%s values are not supported", e.kind)
+}
+
+// Is makes unsupportedKindError match ErrIncompatibleType
+func (e *unsupportedKindError) Is(target error) bool {
+	return target == ErrIncompatibleType
+}
+
+// ConversionError is returned when the conversion of an element within a map, slice or struct fails.
+// It records the path to the offending element, e.g. users[3].age. It is also returned when a value
+// can't be converted at all, such as with ErrIncompatibleType, recording the value and the types involved
+type ConversionError struct {
+	Path       string       // path to the element that failed to convert, empty for the top-level value
+	Source     interface{}  // value that failed to convert, if known
+	SourceType reflect.Type // type of the value that failed to convert, if known
+	TargetType reflect.Type // type the value failed to convert to, if known
+	Err        error        // underlying error
+}
+
+// Error returns the error message, prefixed by the path to the element that failed to convert
+// and describing the value that failed to convert if known
+func (e *ConversionError) Error() string {
+	msg := e.Err.Error()
+	if e.TargetType != nil {
+		msg = fmt.Sprintf("cannot convert %#v of type %s to %s: %s", e.Source, e.SourceType, e.TargetType, msg)
+	}
+	if e.Path != "" {
+		msg = e.Path + ": " + msg
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// indexElement returns the path element that refers to the given slice index
+func indexElement(i int) string {
+	return fmt.Sprintf("[%d]", i)
+}
+
+// joinPath joins path elements, separating names with dots
+func joinPath(elements []string) string {
+	var b strings.Builder
+	for i, element := range elements {
+		if i > 0 && !strings.HasPrefix(element, "[") {
+			b.WriteString(".")
+		}
+		b.WriteString(element)
+	}
+	return b.String()
+}
+
+// pathError wraps err in a ConversionError, prepending the given element to its path
+func pathError(err error, element string) error {
+	if ce, ok := err.(*ConversionError); ok {
+		wrapped := *ce
+		wrapped.Path = joinPath([]string{element, ce.Path})
+		if ce.Path == "" {
+			wrapped.Path = element
+		}
+		return &wrapped
+	}
+	return &ConversionError{Path: element, Err: err}
+}
+
+// sourceError wraps err in a ConversionError recording the source value that failed to convert to the target type
+func sourceError(err error, source interface{}, targetType reflect.Type) error {
+	return &ConversionError{Source: source, SourceType: reflect.TypeOf(source), TargetType: targetType, Err: err}
+}
+
+// keyError wraps err with the map key that failed to convert, along with its type and the target key type
+func keyError(err error, key reflect.Value, keyType reflect.Type) error {
+	if ce, ok := err.(*ConversionError); ok && ce.Path == "" {
+		err = ce.Err // the key is already described
+	}
+	return fmt.Errorf("cannot convert key %#v of type %s to %s: %w", key.Interface(), key.Type(), keyType, err)
+}
@@ -0,0 +1,168 @@
+package elastic_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+type User struct {
+	Name string
+	Age  int
+}
+
+type UserList struct {
+	Users []User
+}
+
+func TestConversionErrorPath(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	source := map[string]interface{}{
+		"Users": []interface{}{
+			map[string]interface{}{"Name": "john", "Age": 30},
+			map[string]interface{}{"Name": "paul", "Age": "old"},
+		},
+	}
+
+	var list UserList
+	err := elastic.Set(&list, source)
+	t.MustFail(err, "Expected conversion to fail")
+
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("Users[1].Age", conversionError.Path)
+	t.Assert(errors.Unwrap(err) != nil, "Expected the error to wrap the original error")
+
+	var m map[string][]int
+	err = elastic.Set(&m, map[string]interface{}{"a": []interface{}{1, Point32{}}})
+	t.Equals("a[1]: cannot convert elastic_test.Point32{X:0, Y:0} of type elastic_test.Point32 to int: Incompatible types", err.Error())
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected error to be ErrIncompatibleType")
+
+	var ints [][]int
+	err = elastic.Set(&ints, [][]interface{}{{1}, {2, "300", "x"}})
+	t.MustFail(err, "Expected conversion to fail")
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("[1][2]", conversionError.Path)
+
+	var users map[User]int
+	err = elastic.Set(&users, map[string]int{"a": 1})
+	t.Equals(`a: cannot convert key "a" of type string to elastic_test.User: Incompatible types`, err.Error())
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected error to be ErrIncompatibleType")
+}
+
+func TestIncompatibleTypeError(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	var i int
+	err := elastic.Set(&i, Point3D{X: 1})
+	t.Assert(errors.Is(err, elastic.ErrIncompatibleType), "Expected ErrIncompatibleType, got %v", err)
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("", conversionError.Path)
+	t.Equals(Point3D{X: 1}, conversionError.Source)
+	t.Equals(reflect.TypeOf(Point3D{}), conversionError.SourceType)
+	t.Equals(reflect.TypeOf(0), conversionError.TargetType)
+	t.Equals(`cannot convert elastic_test.Point3D{X:1, Y:"", Z:0, w:0} of type elastic_test.Point3D to int: Incompatible types`, err.Error())
+
+	// nested elements record the element that failed along with its path
+	var m map[string]int
+	err = elastic.Set(&m, map[string]interface{}{"a": true, "b": []int{1}})
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("b", conversionError.Path)
+	t.Equals([]int{1}, conversionError.Source)
+	t.Equals(reflect.TypeOf(0), conversionError.TargetType)
+}
+
+func TestParseError(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	tests := []struct {
+		source     interface{}
+		targetType reflect.Type
+	}{
+		{"x", reflect.TypeOf(0)},
+		{"x", reflect.TypeOf(uint(0))},
+		{"x", reflect.TypeOf(0.0)},
+		{"x", reflect.TypeOf(complex128(0))},
+		{"x", reflect.TypeOf(false)},
+		{"x", reflect.TypeOf(time.Time{})},
+		{"x", reflect.TypeOf(time.Duration(0))},
+		{"x", reflect.TypeOf(new(big.Int))},
+		{json.Number("x"), reflect.TypeOf(0)},
+	}
+	for _, test := range tests {
+		t.StartSubTest("Parsing %v as %s", test.source, test.targetType)
+		_, err := elastic.Convert(test.source, test.targetType)
+		t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+		t.Assert(!errors.Is(err, elastic.ErrIncompatibleType), "Expected error not to be ErrIncompatibleType")
+	}
+
+	_, err := elastic.Convert("x", reflect.TypeOf(0))
+	var numErr *strconv.NumError
+	t.Assert(errors.As(errors.Unwrap(err), &numErr), "Expected the error to unwrap to a *strconv.NumError")
+	t.Equals("Parse error: strconv.ParseInt: parsing \"x\": invalid syntax", err.Error())
+
+	// out of range values are overflows rather than parse errors
+	_, err = elastic.Convert("300", reflect.TypeOf(int8(0)))
+	t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected ErrOverflow, got %v", err)
+	t.Assert(!errors.Is(err, elastic.ErrParse), "Expected error not to be ErrParse")
+
+	engine := elastic.New()
+	engine.ByteStringEncoding = elastic.Hex
+	_, err = engine.Convert("xyz", reflect.TypeOf([]byte{}))
+	t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+}
+
+func TestSliceOverflow(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	_, err := elastic.Convert([]int{2, -1}, reflect.TypeOf([]uint8{}))
+	t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected ErrOverflow, got %v", err)
+	t.Equals("[1]: Value overflows target type: -1 does not fit in uint8", err.Error())
+
+	_, err = elastic.Convert([]int64{1, 2, 300}, reflect.TypeOf([3]int8{}))
+	t.Equals("[2]: Value overflows target type: 300 does not fit in int8", err.Error())
+
+	_, err = elastic.Convert([]interface{}{"1", 2.5, uint64(1 << 63)}, reflect.TypeOf([]int64{}))
+	t.Equals("[2]: Value overflows target type: 9223372036854775808 does not fit in int64", err.Error())
+
+	t.Equals([]uint8{0, 255}, elastic.MustConvert([]int{0, 255}, reflect.TypeOf([]uint8{})))
+}
+
+func TestDuplicateKey(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	t.Equals(map[string]string{"1": "a", "2": "b"}, elastic.MustConvert(map[int]string{1: "a", 2: "b"}, reflect.TypeOf(map[string]string{})))
+
+	source := map[string]int{"1": 1, "01": 2, "2": 3}
+	_, err := elastic.Convert(source, reflect.TypeOf(map[int]int{}))
+	t.Assert(errors.Is(err, elastic.ErrDuplicateKey), "Expected ErrDuplicateKey, got %v", err)
+	t.Assert(strings.Contains(err.Error(), `keys "`), "Expected the colliding keys in %q", err)
+
+	engine := elastic.New()
+	engine.OnError = elastic.UseZero
+	var skipped []error
+	engine.OnSkippedError = func(err error) {
+		skipped = append(skipped, err)
+	}
+	result, err := engine.Convert(source, reflect.TypeOf(map[int]int{}))
+	t.Ok(err)
+	t.Equals(2, len(result.(map[int]int)))
+	t.Equals(3, result.(map[int]int)[2])
+	t.Equals(1, len(skipped))
+	t.Assert(errors.Is(skipped[0], elastic.ErrDuplicateKey), "Expected ErrDuplicateKey, got %v", skipped[0])
+}
@@ -0,0 +1,25 @@
+package elastic
+
+import (
+	"reflect"
+)
+
+// To converts the source value to type T using the default engine
+func To[T any](source interface{}) (T, error) {
+	var zero T
+	result, err := Default.Convert(source, reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil || result == nil {
+		return zero, err
+	}
+	return result.(T), nil
+}
+
+// MustTo converts the source value to type T using the default engine
+// and panics if the conversion fails
+func MustTo[T any](source interface{}) T {
+	result, err := To[T](source)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
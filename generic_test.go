@@ -0,0 +1,36 @@
+package elastic_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+func TestTo(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	i, err := elastic.To[int]("42")
+	t.Ok(err)
+	t.Equals(42, i)
+
+	ints, err := elastic.To[[]int]([]interface{}{"1", 2.0, 3})
+	t.Ok(err)
+	t.Equals([]int{1, 2, 3}, ints)
+
+	s, err := elastic.To[fmt.Stringer](nil)
+	t.Ok(err)
+	t.Equals(nil, s)
+
+	_, err = elastic.To[int]("XYZ")
+	t.MustFail(err, "Expected conversion to fail")
+
+	t.Equals(float32(1.5), elastic.MustTo[float32]("1.5"))
+
+	defer func() {
+		t.Assert(recover() != nil, "Expected MustTo to panic")
+	}()
+	elastic.MustTo[int]("XYZ")
+}
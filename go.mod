@@ -1,6 +1,6 @@
 module github.com/epiclabs-io/elastic
 
-go 1.13
+go 1.18
 
 require (
 	github.com/epiclabs-io/diff3 v0.0.0-20181217103619-05282cece609 // indirect
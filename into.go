@@ -0,0 +1,89 @@
+package elastic
+
+import (
+	"reflect"
+)
+
+// ConvertInto converts the source value into the existing value the target points to, reusing it instead of
+// allocating a new one: map entries are merged into an existing map, slices reuse their backing array if it has
+// enough capacity and struct fields without a matching source field are left untouched.
+// Other types are set as in Set
+func (ce *ConverterEngine) ConvertInto(target, source interface{}) error {
+	T := reflect.ValueOf(target)
+	if T.Kind() != reflect.Ptr {
+		return ErrExpectedPointer
+	}
+	if T.IsNil() {
+		return ErrNilPointer
+	}
+	return ce.convertInto(new(conversion), T.Elem(), source)
+}
+
+// convertInto converts the source value into the given existing value as part of the given conversion
+func (ce *ConverterEngine) convertInto(c *conversion, T reflect.Value, source interface{}) error {
+	S := reflect.ValueOf(source)
+	for S.Kind() == reflect.Ptr && !S.IsNil() {
+		S = S.Elem() // see through pointers to find the value to convert from
+	}
+	if !S.IsValid() || (S.Kind() == reflect.Ptr && S.IsNil()) || ce.plan(S.Type(), T.Type()).custom() {
+		return ce.setValue(c, T, source)
+	}
+
+	switch {
+	case S.Kind() == reflect.Map && T.Kind() == reflect.Map:
+		if T.IsNil() {
+			T.Set(reflect.MakeMapWithSize(T.Type(), S.Len()))
+		}
+		return ce.fillMap(c, S, T)
+	case isList(S.Kind()) && T.Kind() == reflect.Slice:
+		if T.Cap() >= S.Len() {
+			T.SetLen(S.Len())
+		} else {
+			T.Set(reflect.MakeSlice(T.Type(), S.Len(), S.Len()))
+		}
+		return ce.fillSlice(c, S, T)
+	case S.Kind() == reflect.Struct && T.Kind() == reflect.Struct && S.Type() != T.Type():
+		return ce.fillStruct(c, S, T, false)
+	case S.Kind() == reflect.Map && T.Kind() == reflect.Struct:
+		return ce.fillStructFromMap(c, S, T, false)
+	}
+	return ce.setValue(c, T, source)
+}
+
+// Populate converts each of the sources in order into the existing value the target points to, as in ConvertInto,
+// so that later sources override earlier ones only where they provide a value, such as the keys present in a map
+// when populating a struct. Nil sources are skipped. Required struct fields are checked once all the sources are
+// applied, so that each of them only needs to be provided by one source.
+// This is useful to layer configuration out of several sources
+func (ce *ConverterEngine) Populate(target interface{}, sources ...interface{}) error {
+	T := reflect.ValueOf(target)
+	if T.Kind() != reflect.Ptr {
+		return ErrExpectedPointer
+	}
+	if T.IsNil() {
+		return ErrNilPointer
+	}
+	// required fields only need to be provided by one of the sources
+	c := &conversion{required: &requiredFields{assigned: make(map[string]bool)}}
+	for _, source := range sources {
+		if source == nil {
+			continue
+		}
+		if err := ce.convertInto(c, T.Elem(), source); err != nil {
+			return err
+		}
+	}
+	return c.required.check()
+}
+
+// ConvertInto converts the source value into the existing value the target points to using the default engine,
+// merging maps, reusing slices and leaving struct fields without a matching source field untouched
+func ConvertInto(target, source interface{}) error {
+	return Default.ConvertInto(target, source)
+}
+
+// Populate converts each of the sources in order into the existing value the target points to using the default engine,
+// so that later sources override earlier ones only where they provide a value
+func Populate(target interface{}, sources ...interface{}) error {
+	return Default.Populate(target, sources...)
+}
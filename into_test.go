@@ -0,0 +1,135 @@
+package elastic_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+func TestConvertInto(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	m := map[string]int{"a": 1, "b": 2}
+	err := elastic.ConvertInto(&m, map[string]string{"b": "20", "c": "30"})
+	t.Ok(err)
+	t.Equals(map[string]int{"a": 1, "b": 20, "c": 30}, m)
+
+	var empty map[string]int
+	t.Ok(elastic.ConvertInto(&empty, map[string]string{"x": "1"}))
+	t.Equals(map[string]int{"x": 1}, empty)
+
+	buffer := make([]int, 1, 10)
+	t.Ok(elastic.ConvertInto(&buffer, []string{"1", "2", "3"}))
+	t.Equals([]int{1, 2, 3}, buffer)
+	t.Equals(10, cap(buffer))
+	backing := &buffer[:1][0]
+	t.Ok(elastic.ConvertInto(&buffer, [2]float64{4, 5}))
+	t.Equals([]int{4, 5}, buffer)
+	t.Assert(backing == &buffer[0], "Expected the backing array to be reused")
+
+	t.Ok(elastic.ConvertInto(&buffer, make([]float64, 20)))
+	t.Equals(20, len(buffer))
+
+	user := User{Name: "john", Age: 30}
+	t.Ok(elastic.ConvertInto(&user, map[string]interface{}{"Age": "31"}))
+	t.Equals(User{Name: "john", Age: 31}, user)
+	t.Ok(elastic.ConvertInto(&user, struct{ Name []byte }{[]byte("paul")}))
+	t.Equals(User{Name: "paul", Age: 31}, user)
+	t.Ok(elastic.ConvertInto(&user, &User{Name: "george"}))
+	t.Equals(User{Name: "george"}, user)
+
+	// other types are set as usual
+	var i int
+	t.Ok(elastic.ConvertInto(&i, "5"))
+	t.Equals(5, i)
+	t.Ok(elastic.ConvertInto(&m, nil))
+	t.Assert(m == nil, "Expected nil to set the zero value")
+
+	err = elastic.ConvertInto(&user, map[string]interface{}{"Age": "old"})
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("Age", conversionError.Path)
+
+	err = elastic.ConvertInto(user, map[string]interface{}{})
+	t.MustFailWith(err, elastic.ErrExpectedPointer)
+	var pm *map[string]int
+	err = elastic.ConvertInto(pm, map[string]interface{}{})
+	t.MustFailWith(err, elastic.ErrNilPointer)
+}
+
+func TestPopulate(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	type Config struct {
+		Host    string
+		Port    int
+		Debug   bool
+		Timeout time.Duration
+	}
+
+	defaults := map[string]interface{}{"Host": "localhost", "Port": 80, "Timeout": "5s"}
+	file := map[string]string{"Port": "8080", "Debug": "true"}
+	env := map[string]interface{}{"Host": "example.com"}
+
+	var config Config
+	t.Ok(elastic.Populate(&config, defaults, nil, file, env))
+	t.Equals(Config{Host: "example.com", Port: 8080, Debug: true, Timeout: 5 * time.Second}, config)
+
+	err := elastic.Populate(&config, map[string]interface{}{"Port": "none"}, env)
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("Port", conversionError.Path)
+
+	err = elastic.Populate(config, defaults)
+	t.MustFailWith(err, elastic.ErrExpectedPointer)
+
+	// required fields only need to be provided by one of the sources
+	type Listener struct {
+		Host string `elastic:"host,required"`
+	}
+	type Service struct {
+		Name     string `elastic:"name,required"`
+		Port     int
+		Listener Listener
+	}
+	var service Service
+	t.Ok(elastic.Populate(&service, map[string]interface{}{"name": "api", "Port": 1}, map[string]interface{}{"Port": 2}))
+	t.Equals(Service{Name: "api", Port: 2}, service)
+
+	service = Service{}
+	t.Ok(elastic.Populate(&service, map[string]interface{}{"Port": 1}, map[string]interface{}{"name": "api"}))
+	t.Equals(Service{Name: "api", Port: 1}, service)
+
+	// fields explicitly set to their zero value are provided
+	service = Service{}
+	t.Ok(elastic.Populate(&service, map[string]interface{}{"name": ""}, map[string]interface{}{"Port": 2}))
+	t.Equals(Service{Port: 2}, service)
+
+	service = Service{}
+	err = elastic.Populate(&service, map[string]interface{}{"Port": 1}, map[string]interface{}{"Port": 2})
+	t.Assert(errors.Is(err, elastic.ErrMissingFields), "Expected ErrMissingFields, got %v", err)
+	t.Equals("Missing fields: name", err.Error())
+
+	// nested required fields are named by their path
+	service = Service{}
+	err = elastic.Populate(&service,
+		map[string]interface{}{"name": "api", "Listener": map[string]interface{}{}},
+		map[string]interface{}{"Listener": map[string]interface{}{}})
+	t.Equals("Missing fields: Listener.host", err.Error())
+
+	service = Service{}
+	t.Ok(elastic.Populate(&service,
+		map[string]interface{}{"name": "api", "Listener": map[string]interface{}{}},
+		map[string]interface{}{"Listener": map[string]interface{}{"host": "localhost"}}))
+	t.Equals(Service{Name: "api", Listener: Listener{Host: "localhost"}}, service)
+
+	// a single source must provide the required fields, whatever the existing value
+	service = Service{Name: "web"}
+	err = elastic.ConvertInto(&service, map[string]interface{}{"Port": 3})
+	t.Assert(errors.Is(err, elastic.ErrMissingFields), "Expected ErrMissingFields, got %v", err)
+}
@@ -0,0 +1,90 @@
+package elastic
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+)
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+var numberType = reflect.TypeOf(json.Number(""))
+
+func init() {
+	builtinSourceConverters[rawMessageType] = convertFromRawMessage
+	builtinTargetConverters[rawMessageType] = convertToRawMessage
+	builtinSourceConverters[numberType] = convertFromNumber
+	builtinTargetConverters[numberType] = convertToNumber
+}
+
+// convertFromRawMessage unmarshals a json.RawMessage into the target type.
+// Strings and byte slices get the raw JSON as is, regardless of the engine's byte string encoding and charset
+func convertFromRawMessage(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	switch {
+	case targetType.Kind() == reflect.String:
+		return string(source.(json.RawMessage)), nil
+	case isBytes(targetType):
+		return append([]byte(nil), source.(json.RawMessage)...), nil
+	}
+	T := reflect.New(targetType)
+	if err := json.Unmarshal(source.(json.RawMessage), T.Interface()); err != nil {
+		return nil, err
+	}
+	return T.Elem().Interface(), nil
+}
+
+// convertToRawMessage marshals the source value into a json.RawMessage.
+// Strings and byte slices are assumed to contain JSON already and are taken as is, regardless of the engine's
+// byte string encoding and charset
+func convertToRawMessage(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	switch {
+	case S.Kind() == reflect.String:
+		return json.RawMessage(S.String()), nil
+	case isBytes(S.Type()):
+		return json.RawMessage(append([]byte(nil), S.Bytes()...)), nil
+	}
+	b, err := json.Marshal(source)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(b), nil
+}
+
+// convertFromNumber converts a json.Number, as produced by json.Decoder.UseNumber(), to strings and numbers.
+// Integers are parsed as such to avoid losing precision, falling back to floats that are then rounded
+func convertFromNumber(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	n := source.(json.Number)
+	switch kind := targetType.Kind(); {
+	case kind == reflect.String:
+		return n.String(), nil
+	case isInt(kind):
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+	case isUint(kind):
+		if u, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+			return u, nil
+		}
+	case !isFloat(kind):
+		return nil, ErrNoConversionAvailable
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, parseError(err, targetType)
+	}
+	return f, nil
+}
+
+// convertToNumber converts numeric strings, which may use the engine's separators, to a json.Number.
+// Other sources are left to the default conversion, which formats numbers as strings
+func convertToNumber(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	if S.Kind() != reflect.String || S.Type() == numberType {
+		return nil, ErrNoConversionAvailable
+	}
+	s, err := ce.normalizeNumber(S.String())
+	if err != nil {
+		return nil, parseError(err, targetType)
+	}
+	return json.Number(s), nil
+}
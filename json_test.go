@@ -0,0 +1,116 @@
+package elastic_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+func TestRawMessage(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	rawType := reflect.TypeOf(json.RawMessage{})
+
+	raw, err := elastic.Convert(Point32{X: 1, Y: 2}, rawType)
+	t.Ok(err)
+	t.Equals(json.RawMessage(`{"X":1,"Y":2}`), raw)
+
+	raw, err = elastic.Convert(map[string]interface{}{"a": []int{1, 2}}, rawType)
+	t.Ok(err)
+	t.Equals(json.RawMessage(`{"a":[1,2]}`), raw)
+
+	raw, err = elastic.Convert(`{"already":"json"}`, rawType)
+	t.Ok(err)
+	t.Equals(json.RawMessage(`{"already":"json"}`), raw)
+
+	var p Point32
+	err = elastic.Set(&p, json.RawMessage(`{"X":3,"Y":4}`))
+	t.Ok(err)
+	t.Equals(Point32{X: 3, Y: 4}, p)
+
+	var m map[string]interface{}
+	err = elastic.Set(&m, json.RawMessage(`{"a":"b"}`))
+	t.Ok(err)
+	t.Equals(map[string]interface{}{"a": "b"}, m)
+
+	var s string
+	err = elastic.Set(&s, json.RawMessage(`{"a":"b"}`))
+	t.Ok(err)
+	t.Equals(`{"a":"b"}`, s)
+
+	err = elastic.Set(&p, json.RawMessage(`{"X":`))
+	t.MustFail(err, "Expected unmarshaling to fail")
+
+	_, err = elastic.Convert(make(chan int), rawType)
+	t.MustFail(err, "Expected marshaling to fail")
+
+	// raw JSON is never subject to the byte string encoding
+	engine := elastic.NewWithOptions(elastic.WithByteStringEncoding(elastic.Base64))
+	var doc struct {
+		Raw json.RawMessage
+	}
+	t.Ok(engine.Set(&doc, map[string]interface{}{"Raw": `{"a":"b"}`}))
+	t.Equals(json.RawMessage(`{"a":"b"}`), doc.Raw)
+
+	t.Ok(engine.Set(&s, doc.Raw))
+	t.Equals(`{"a":"b"}`, s)
+
+	var fields map[string]string
+	t.Ok(engine.Set(&fields, doc))
+	t.Equals(map[string]string{"Raw": `{"a":"b"}`}, fields)
+
+	// while other byte slices still are
+	t.Ok(engine.Set(&s, []byte(`{"a":"b"}`)))
+	t.Equals("eyJhIjoiYiJ9", s)
+}
+
+func TestNumber(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	t.Equals(42, elastic.MustConvert(json.Number("42"), reflect.TypeOf(0)))
+	t.Equals(int64(9007199254740993), elastic.MustConvert(json.Number("9007199254740993"), reflect.TypeOf(int64(0))))
+	t.Equals(uint64(18446744073709551615), elastic.MustConvert(json.Number("18446744073709551615"), reflect.TypeOf(uint64(0))))
+	t.Equals(2, elastic.MustConvert(json.Number("2.7"), reflect.TypeOf(0)))
+	t.Equals(float32(1.5), elastic.MustConvert(json.Number("1.5"), reflect.TypeOf(float32(0))))
+	t.Equals("1e3", elastic.MustConvert(json.Number("1e3"), reflect.TypeOf("")))
+	t.Equals(StringAlias("7"), elastic.MustConvert(json.Number("7"), reflect.TypeOf(StringAlias(""))))
+
+	_, err := elastic.Convert(json.Number("300"), reflect.TypeOf(int8(0)))
+	t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected overflow, got %v", err)
+	_, err = elastic.Convert(json.Number("-1"), reflect.TypeOf(uint(0)))
+	t.Assert(errors.Is(err, elastic.ErrOverflow), "Expected overflow, got %v", err)
+	_, err = elastic.Convert(json.Number("abc"), reflect.TypeOf(0))
+	t.MustFail(err, "Expected parsing to fail")
+
+	// json.Number is recognized as a number even when string parsing is disabled
+	engine := elastic.New()
+	engine.DisableStringParsing = true
+	t.Equals(42.0, engine.MustConvert(json.Number("42"), reflect.TypeOf(0.0)))
+
+	decoder := json.NewDecoder(strings.NewReader(`{"Name":"john","Age":30}`))
+	decoder.UseNumber()
+	var m map[string]interface{}
+	t.Ok(decoder.Decode(&m))
+	var user User
+	t.Ok(elastic.Set(&user, m))
+	t.Equals(User{Name: "john", Age: 30}, user)
+
+	// strings converted to json.Number are parsed with the engine's separators
+	numberType := reflect.TypeOf(json.Number(""))
+	t.Equals(json.Number("42"), elastic.MustConvert(42, numberType))
+	t.Equals(json.Number("1.5"), elastic.MustConvert("1.5", numberType))
+	t.Equals(json.Number("2.5"), elastic.MustConvert(json.Number("2.5"), numberType))
+
+	engine = elastic.NewWithOptions(elastic.WithSeparators(',', '.'))
+	t.Equals(json.Number("1234.5"), engine.MustConvert("1.234,5", numberType))
+	t.Equals(json.Number("2.5"), engine.MustConvert(json.Number("2.5"), numberType))
+	_, err = engine.Convert("1.23,4", numberType)
+	t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+}
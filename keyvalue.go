@@ -0,0 +1,38 @@
+package elastic
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// KeyValueSetter can be implemented by map-like types that are not Go maps, such as ordered maps,
+// so that they can be populated out of a map, receiving its entries one by one
+type KeyValueSetter interface {
+	SetKeyValue(k, v interface{}) error
+}
+
+var keyValueSetterType = reflect.TypeOf((*KeyValueSetter)(nil)).Elem()
+
+// setKeyValues builds a target implementing KeyValueSetter by feeding it the entries of the source map.
+// Since Go maps have no stable order, entries are fed in an unspecified order unless the engine's
+// SortMapEntries option is set
+func (ce *ConverterEngine) setKeyValues(c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	T := reflect.New(targetType)
+	setter := T.Interface().(KeyValueSetter)
+	keys := S.MapKeys()
+	if ce.SortMapEntries {
+		sortKeys(keys)
+	}
+	for _, key := range keys {
+		if c.ctx != nil {
+			if err := c.ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if err := setter.SetKeyValue(key.Interface(), S.MapIndex(key).Interface()); err != nil {
+			return nil, pathError(err, fmt.Sprint(key))
+		}
+	}
+	return T.Elem().Interface(), nil
+}
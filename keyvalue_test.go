@@ -0,0 +1,54 @@
+package elastic_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+type OrderedMap struct {
+	Keys   []string
+	Values map[string]interface{}
+}
+
+func (om *OrderedMap) SetKeyValue(k, v interface{}) error {
+	key, ok := k.(string)
+	if !ok {
+		return fmt.Errorf("unsupported key %v", k)
+	}
+	if om.Values == nil {
+		om.Values = make(map[string]interface{})
+	}
+	om.Keys = append(om.Keys, key)
+	om.Values[key] = v
+	return nil
+}
+
+func TestKeyValueSetter(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	engine := elastic.New()
+	engine.SortMapEntries = true
+
+	om := engine.MustConvert(map[string]int{"b": 2, "c": 3, "a": 1}, reflect.TypeOf(OrderedMap{})).(OrderedMap)
+	t.Equals([]string{"a", "b", "c"}, om.Keys)
+	t.Equals(map[string]interface{}{"a": 1, "b": 2, "c": 3}, om.Values)
+
+	var pom *OrderedMap
+	t.Ok(engine.Set(&pom, map[string]string{"x": "y"}))
+	t.Equals([]string{"x"}, pom.Keys)
+
+	var nested struct{ Settings OrderedMap }
+	t.Ok(elastic.Set(&nested, map[string]interface{}{"Settings": map[string]bool{"debug": true}}))
+	t.Equals(map[string]interface{}{"debug": true}, nested.Settings.Values)
+
+	_, err := engine.Convert(map[int]int{1: 1}, reflect.TypeOf(OrderedMap{}))
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("1", conversionError.Path)
+}
@@ -0,0 +1,147 @@
+package elastic
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Merge deep-merges the source value into the existing value the target points to, converting the source values
+// to the types of the target's fields and elements. Unlike ConvertInto, nested maps and structs are merged
+// recursively rather than replaced as a whole, so that the target keeps the values the source doesn't provide:
+// missing map keys, nil values and, for struct sources, zero fields. Structs also merge into maps with string keys.
+// Other values, such as slices, are replaced.
+// Required fields are not enforced, as the source is expected to hold only the values to override
+func (ce *ConverterEngine) Merge(target, source interface{}) error {
+	T := reflect.ValueOf(target)
+	if T.Kind() != reflect.Ptr {
+		return ErrExpectedPointer
+	}
+	if T.IsNil() {
+		return ErrNilPointer
+	}
+	return ce.merge(new(conversion), T.Elem(), source)
+}
+
+// merge merges the source value into the given existing value as part of the given conversion
+func (ce *ConverterEngine) merge(c *conversion, T reflect.Value, source interface{}) error {
+	S := reflect.ValueOf(source)
+	v, entered, err := c.enter(S, T.Type())
+	if err != nil {
+		return err
+	}
+	if entered {
+		defer c.leave(v)
+	}
+	for S.Kind() == reflect.Ptr && !S.IsNil() {
+		S = S.Elem() // see through pointers to find the value to merge from
+	}
+	if isNil(S) {
+		return nil // there is nothing to merge
+	}
+	if !ce.plan(S.Type(), T.Type()).custom() {
+		switch {
+		case T.Kind() == reflect.Ptr && !T.IsNil() && isMergeable(T.Type().Elem()):
+			return ce.merge(c, T.Elem(), S.Interface())
+		case T.Kind() == reflect.Interface && !T.IsNil() && isMergeable(T.Elem().Type()):
+			value := reflect.New(T.Elem().Type()).Elem() // the value held by an interface can't be modified in place
+			value.Set(T.Elem())
+			if err := ce.merge(c, value, S.Interface()); err != nil {
+				return err
+			}
+			T.Set(value)
+			return nil
+		case S.Kind() == reflect.Map && T.Kind() == reflect.Map:
+			if T.IsNil() {
+				T.Set(reflect.MakeMapWithSize(T.Type(), S.Len()))
+			}
+			return ce.mergeMap(c, S, T)
+		case S.Kind() == reflect.Map && T.Kind() == reflect.Struct:
+			return ce.fillStructFromMap(c, S, T, true)
+		case S.Kind() == reflect.Struct && T.Kind() == reflect.Struct && isMergeable(T.Type()):
+			return ce.fillStruct(c, S, T, true)
+		case S.Kind() == reflect.Struct && T.Kind() == reflect.Map && T.Type().Key().Kind() == reflect.String && isMergeable(S.Type()):
+			converted, err := ce.convertStructToMap(c, S.Interface(), T.Type(), true)
+			if err != nil {
+				return err
+			}
+			if T.IsNil() {
+				T.Set(reflect.MakeMapWithSize(T.Type(), S.NumField()))
+			}
+			return ce.mergeMap(c, reflect.ValueOf(converted), T)
+		}
+	}
+	if entered {
+		c.leave(v) // values that are not merged are converted as a whole, which tracks them again
+	}
+	return ce.setValue(c, T, source)
+}
+
+// mergeMap merges the entries of the source map into the target map, merging the values of the keys present
+// in both and skipping nil values
+func (ce *ConverterEngine) mergeMap(c *conversion, S, T reflect.Value) error {
+	keyType := T.Type().Key()
+	elementType := T.Type().Elem()
+
+	for i := S.MapRange(); i.Next(); {
+		if isNil(i.Value()) {
+			continue
+		}
+		element := fmt.Sprint(i.Key())
+		key, err := ce.convert(c, i.Key().Interface(), keyType)
+		if err != nil {
+			err = keyError(err, i.Key(), keyType)
+			if ce.OnError == UseZero {
+				ce.skip(err, joinPath(append(c.path, element)))
+				continue // entries with unconvertible keys are left out
+			}
+			return pathError(err, element)
+		}
+		k := valueOf(key, keyType)
+		existing := T.MapIndex(k)
+		if !existing.IsValid() {
+			existing = reflect.Zero(elementType)
+		}
+		value, err := ce.mergeElement(c, element, existing, i.Value().Interface())
+		if err != nil {
+			return err
+		}
+		T.SetMapIndex(k, valueOf(value, elementType))
+	}
+	return nil
+}
+
+// mergeElement merges a nested element, identified by the given path element, into a copy of its existing value,
+// handling errors the same way as convertElement
+func (ce *ConverterEngine) mergeElement(c *conversion, element string, existing reflect.Value, source interface{}) (interface{}, error) {
+	return ce.convertElementWith(c, element, existing.Type(), func() (interface{}, error) {
+		T := reflect.New(existing.Type()).Elem()
+		T.Set(existing)
+		if err := ce.merge(c, T, source); err != nil {
+			return nil, err
+		}
+		return T.Interface(), nil
+	})
+}
+
+// isMergeable returns true if values of the given type are merged rather than replaced, that is, maps and structs
+// the engine doesn't convert out of the box, such as time.Time
+func isMergeable(t reflect.Type) bool {
+	return t.Kind() == reflect.Map || (t.Kind() == reflect.Struct && !hasBuiltinConverter(t))
+}
+
+// isNil returns true if the value is invalid or a nil pointer, interface, map or slice
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// Merge deep-merges the source value into the existing value the target points to using the default engine,
+// merging nested maps and structs recursively
+func Merge(target, source interface{}) error {
+	return Default.Merge(target, source)
+}
@@ -0,0 +1,146 @@
+package elastic_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+type ServerConfig struct {
+	Host    string
+	Port    int
+	Timeout time.Duration
+	TLS     *TLSConfig
+	Labels  map[string]string
+	Tags    []string
+}
+
+type TLSConfig struct {
+	Enabled bool
+	Cert    string
+}
+
+func TestMerge(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	base := ServerConfig{
+		Host:    "localhost",
+		Port:    80,
+		Timeout: time.Second,
+		TLS:     &TLSConfig{Cert: "server.pem"},
+		Labels:  map[string]string{"env": "dev", "team": "core"},
+		Tags:    []string{"a", "b"},
+	}
+
+	// maps override only the keys they have, converting their values, and merge nested maps and structs
+	config := base
+	config.TLS = &TLSConfig{Cert: "server.pem"}
+	err := elastic.Merge(&config, map[string]interface{}{
+		"Port":    "8080",
+		"Timeout": "5s",
+		"TLS":     map[string]interface{}{"Enabled": true},
+		"Labels":  map[string]interface{}{"env": "prod"},
+		"Tags":    []string{"c"},
+		"Host":    nil,
+	})
+	t.Ok(err)
+	t.Equals(ServerConfig{
+		Host:    "localhost",
+		Port:    8080,
+		Timeout: 5 * time.Second,
+		TLS:     &TLSConfig{Enabled: true, Cert: "server.pem"},
+		Labels:  map[string]string{"env": "prod", "team": "core"},
+		Tags:    []string{"c"}, // slices are replaced
+	}, config)
+
+	// structs override only their non-zero fields
+	config = base
+	config.TLS = nil
+	config.Labels = nil
+	t.Ok(elastic.Merge(&config, ServerConfig{Port: 443, TLS: &TLSConfig{Enabled: true}, Labels: map[string]string{"env": "prod"}}))
+	t.Equals(ServerConfig{
+		Host:    "localhost",
+		Port:    443,
+		Timeout: time.Second,
+		TLS:     &TLSConfig{Enabled: true},
+		Labels:  map[string]string{"env": "prod"},
+		Tags:    []string{"a", "b"},
+	}, config)
+
+	// nested maps of interfaces are merged recursively
+	settings := map[string]interface{}{
+		"db":    map[string]interface{}{"host": "localhost", "port": 5432},
+		"debug": false,
+	}
+	t.Ok(elastic.Merge(&settings, map[string]interface{}{
+		"db":    map[string]interface{}{"port": 6432},
+		"debug": true,
+	}))
+	t.Equals(map[string]interface{}{
+		"db":    map[string]interface{}{"host": "localhost", "port": 6432},
+		"debug": true,
+	}, settings)
+
+	// structs merge into maps, leaving out their zero fields, both at the top level and nested in map values
+	settings = map[string]interface{}{"Host": "localhost", "Port": 80, "Labels": map[string]interface{}{"env": "dev"}}
+	t.Ok(elastic.Merge(&settings, ServerConfig{Port: 443, Labels: map[string]string{"team": "core"}}))
+	t.Equals(map[string]interface{}{
+		"Host":   "localhost",
+		"Port":   443,
+		"Labels": map[string]interface{}{"env": "dev", "team": "core"},
+	}, settings)
+
+	settings = map[string]interface{}{"server": map[string]interface{}{"Host": "localhost", "Port": 80}}
+	t.Ok(elastic.Merge(&settings, map[string]interface{}{"server": ServerConfig{Port: 443, TLS: &TLSConfig{Enabled: true}}}))
+	t.Equals(map[string]interface{}{
+		"server": map[string]interface{}{"Host": "localhost", "Port": 443, "TLS": &TLSConfig{Enabled: true}},
+	}, settings)
+
+	typed := map[string]string{"Host": "localhost", "Port": "80"}
+	t.Ok(elastic.Merge(&typed, ServerConfig{Port: 443}))
+	t.Equals(map[string]string{"Host": "localhost", "Port": "443"}, typed)
+
+	// nested structs merge into nested maps too, unless a converter handles them
+	type Listener struct {
+		Server ServerConfig
+		TLS    TLSConfig
+	}
+	settings = map[string]interface{}{
+		"Server": map[string]interface{}{"Host": "localhost", "Port": 80},
+		"TLS":    map[string]interface{}{"Cert": "cert.pem"},
+	}
+	engine := elastic.New()
+	engine.AddConversion(reflect.TypeOf(TLSConfig{}), reflect.TypeOf(settings), func(source interface{}) (interface{}, error) {
+		return map[string]interface{}{"Enabled": source.(TLSConfig).Enabled}, nil
+	})
+	t.Ok(engine.Merge(&settings, Listener{Server: ServerConfig{Port: 443}, TLS: TLSConfig{Cert: "other.pem"}}))
+	t.Equals(map[string]interface{}{
+		"Server": map[string]interface{}{"Host": "localhost", "Port": 443},
+		"TLS":    map[string]interface{}{"Cert": "cert.pem", "Enabled": false},
+	}, settings)
+
+	// errors report the path to the offending value
+	config = base
+	err = elastic.Merge(&config, map[string]interface{}{"TLS": map[string]interface{}{"Enabled": "maybe"}})
+	t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+	var convErr *elastic.ConversionError
+	t.Assert(errors.As(err, &convErr), "Expected a ConversionError, got %v", err)
+	t.Equals("TLS.Enabled", convErr.Path)
+
+	// required fields are not enforced, since the source only holds the values to override
+	type Required struct {
+		Name string `elastic:",required"`
+		Age  int
+	}
+	r := Required{Name: "ann"}
+	t.Ok(elastic.Merge(&r, map[string]interface{}{"Age": 30}))
+	t.Equals(Required{Name: "ann", Age: 30}, r)
+
+	t.MustFailWith(elastic.Merge(config, base), elastic.ErrExpectedPointer)
+	t.MustFailWith(elastic.Merge((*ServerConfig)(nil), base), elastic.ErrNilPointer)
+}
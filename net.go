@@ -0,0 +1,75 @@
+package elastic
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"reflect"
+)
+
+var ipType = reflect.TypeOf(net.IP{})
+var addrType = reflect.TypeOf(netip.Addr{})
+
+func init() {
+	builtinSourceConverters[ipType] = convertFromIP
+	builtinTargetConverters[ipType] = convertToIP
+	builtinSourceConverters[addrType] = convertFromAddr
+	builtinTargetConverters[addrType] = convertToAddr
+}
+
+// convertFromIP converts a net.IP to a string or a netip.Addr.
+// Conversion to byte slices is left to the default conversion, yielding the raw bytes
+func convertFromIP(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	ip := source.(net.IP)
+	switch {
+	case targetType.Kind() == reflect.String:
+		return ip.String(), nil
+	case targetType == addrType:
+		addr, ok := netip.AddrFromSlice(ip)
+		if !ok {
+			return nil, fmt.Errorf("invalid IP address %v", []byte(ip))
+		}
+		return addr, nil
+	}
+	return nil, ErrNoConversionAvailable
+}
+
+// convertToIP parses a net.IP out of a string
+func convertToIP(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	if S.Kind() != reflect.String {
+		return nil, ErrNoConversionAvailable
+	}
+	ip := net.ParseIP(S.String())
+	if ip == nil {
+		return nil, parseError(fmt.Errorf("invalid IP address %q", S.String()), targetType)
+	}
+	return ip, nil
+}
+
+// convertFromAddr converts a netip.Addr to a string, a net.IP or a byte slice holding its raw bytes
+func convertFromAddr(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	addr := source.(netip.Addr)
+	switch {
+	case targetType.Kind() == reflect.String:
+		return addr.String(), nil
+	case targetType == ipType:
+		return net.IP(addr.AsSlice()), nil
+	case isBytes(targetType):
+		return addr.AsSlice(), nil
+	}
+	return nil, ErrNoConversionAvailable
+}
+
+// convertToAddr parses a netip.Addr out of a string
+func convertToAddr(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	if S.Kind() != reflect.String {
+		return nil, ErrNoConversionAvailable
+	}
+	addr, err := netip.ParseAddr(S.String())
+	if err != nil {
+		return nil, parseError(err, targetType)
+	}
+	return addr, nil
+}
@@ -0,0 +1,55 @@
+package elastic_test
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+func TestIP(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	ipType := reflect.TypeOf(net.IP{})
+	addrType := reflect.TypeOf(netip.Addr{})
+
+	ip := elastic.MustConvert("192.168.1.1", ipType).(net.IP)
+	t.Assert(ip.Equal(net.IPv4(192, 168, 1, 1)), "Expected 192.168.1.1, got %v", ip)
+	t.Equals("192.168.1.1", elastic.MustConvert(ip, reflect.TypeOf("")))
+	t.Equals([]byte(ip), elastic.MustConvert(ip, reflect.TypeOf([]byte{})))
+
+	_, err := elastic.Convert("192.168.1.300", ipType)
+	t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+
+	addr := elastic.MustConvert("::1", addrType).(netip.Addr)
+	t.Equals(netip.IPv6Loopback(), addr)
+	t.Equals("::1", elastic.MustConvert(addr, reflect.TypeOf("")))
+	t.Equals(netip.IPv6Loopback().AsSlice(), elastic.MustConvert(addr, reflect.TypeOf([]byte{})))
+
+	_, err = elastic.Convert("not an address", addrType)
+	t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+
+	v4 := netip.MustParseAddr("10.0.0.1")
+	t.Equals(net.IP(v4.AsSlice()), elastic.MustConvert(v4, ipType))
+	t.Equals(netip.AddrFrom16(v4.As16()), elastic.MustConvert(net.ParseIP("10.0.0.1"), addrType))
+
+	var config struct {
+		Listen netip.Addr
+		Peers  []net.IP
+	}
+	err = elastic.Set(&config, map[string]interface{}{"Listen": "0.0.0.0", "Peers": []string{"10.0.0.2"}})
+	t.Ok(err)
+	t.Equals(netip.IPv4Unspecified(), config.Listen)
+	t.Equals("10.0.0.2", config.Peers[0].String())
+
+	err = elastic.Set(&config, map[string]interface{}{"Peers": []string{"10.0.0.2", "10.0.0"}})
+	t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("Peers[1]", conversionError.Path)
+}
@@ -0,0 +1,300 @@
+package elastic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrOverflow is returned when a numeric value does not fit in the target type
+var ErrOverflow = errors.New("Value overflows target type")
+
+// ErrPrecisionLoss is returned in strict mode when converting a float with a fractional part to an integer
+var ErrPrecisionLoss = errors.New("Conversion loses precision")
+
+// RoundingMode defines how floating point numbers are converted to integers
+type RoundingMode int
+
+const (
+	// Truncate discards the fractional part, rounding toward zero. This is the default
+	Truncate RoundingMode = iota
+	// Round rounds to the nearest integer, rounding half away from zero
+	Round
+	// Floor rounds toward negative infinity
+	Floor
+	// Ceil rounds toward positive infinity
+	Ceil
+	// RoundHalfEven rounds to the nearest integer, rounding half to even
+	RoundHalfEven
+)
+
+// NonFinitePolicy defines how NaN and infinite floats are converted to integers, which can't represent them
+type NonFinitePolicy int
+
+const (
+	// NonFiniteError fails the conversion with ErrOverflow. This is the default
+	NonFiniteError NonFinitePolicy = iota
+	// NonFiniteZero converts NaN and infinities to zero
+	NonFiniteZero
+	// NonFiniteClamp converts +Inf and -Inf to the largest and smallest values of the target type, and NaN to zero
+	NonFiniteClamp
+)
+
+// round applies the rounding mode to the given float
+func (rm RoundingMode) round(f float64) float64 {
+	switch rm {
+	case Round:
+		return math.Round(f)
+	case Floor:
+		return math.Floor(f)
+	case Ceil:
+		return math.Ceil(f)
+	case RoundHalfEven:
+		return math.RoundToEven(f)
+	}
+	return math.Trunc(f)
+}
+
+// isInt returns true if the kind is a signed integer
+func isInt(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+// isUint returns true if the kind is an unsigned integer
+func isUint(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+// isFloat returns true if the kind is a floating point number
+func isFloat(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+// isComplex returns true if the kind is a complex number
+func isComplex(kind reflect.Kind) bool {
+	return kind == reflect.Complex64 || kind == reflect.Complex128
+}
+
+// isNumber returns true if the kind is an integer or a floating point number
+func isNumber(kind reflect.Kind) bool {
+	return isInt(kind) || isUint(kind) || isFloat(kind)
+}
+
+// overflowError builds a descriptive error wrapping ErrOverflow
+func overflowError(value interface{}, targetType reflect.Type) error {
+	return fmt.Errorf("%w: %v does not fit in %s", ErrOverflow, value, targetType)
+}
+
+// integerBase returns the base to parse the given integer string with: 0 to let strconv detect
+// the 0x, 0o and 0b prefixes, or 10 otherwise so that leading zeros are not mistaken for octal
+func integerBase(s string) int {
+	s = strings.TrimLeft(s, "+-")
+	if len(s) > 2 && s[0] == '0' {
+		switch s[1] {
+		case 'x', 'X', 'o', 'O', 'b', 'B':
+			return 0
+		}
+	}
+	return 10
+}
+
+// normalizeNumber rewrites a number formatted with the engine's decimal and thousands separators into the format
+// strconv expects, removing the thousands separators and replacing the decimal separator with a dot.
+// Returns an error if the digits are not grouped by three
+func (ce *ConverterEngine) normalizeNumber(s string) (string, error) {
+	decimal, thousands := ce.DecimalSeparator, ce.ThousandsSeparator
+	if decimal == 0 {
+		decimal = '.'
+	}
+	if decimal == '.' && thousands == 0 {
+		return s, nil
+	}
+	if decimal != '.' && thousands != '.' && strings.ContainsRune(s, '.') {
+		return "", fmt.Errorf("unexpected '.' in %q, the decimal separator is %q", s, decimal)
+	}
+	integer, fraction, hasFraction := strings.Cut(s, string(decimal))
+	if thousands != 0 && strings.ContainsRune(integer, thousands) {
+		digits := strings.TrimLeft(integer, "+-")
+		groups := strings.Split(digits, string(thousands))
+		for i, group := range groups {
+			if len(group) > 3 || len(group) == 0 || (i > 0 && len(group) != 3) {
+				return "", fmt.Errorf("invalid digit grouping in %q", s)
+			}
+		}
+		integer = integer[:len(integer)-len(digits)] + strings.Join(groups, "")
+	}
+	if hasFraction {
+		return integer + "." + fraction, nil
+	}
+	return integer, nil
+}
+
+// roundFloat rounds a float to be converted to an integer according to the engine's rounding mode.
+// In strict mode, floats with a fractional part are rejected instead
+func (ce *ConverterEngine) roundFloat(f float64, targetType reflect.Type) (float64, error) {
+	if ce.Strict {
+		if f != math.Trunc(f) && !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return 0, fmt.Errorf("%w: %v has a fractional part and cannot be converted to %s", ErrPrecisionLoss, f, targetType)
+		}
+		return f, nil
+	}
+	return ce.RoundingMode.round(f), nil
+}
+
+// convertNonFinite converts a NaN or infinite float to an integer type according to the engine's non-finite policy
+func (ce *ConverterEngine) convertNonFinite(f float64, targetType reflect.Type) (interface{}, error) {
+	T := reflect.New(targetType).Elem()
+	switch ce.NonFinite {
+	case NonFiniteZero:
+		return T.Interface(), nil
+	case NonFiniteClamp:
+		bits := targetType.Bits()
+		switch {
+		case math.IsNaN(f):
+		case isInt(targetType.Kind()) && f > 0:
+			T.SetInt(1<<(bits-1) - 1)
+		case isInt(targetType.Kind()):
+			T.SetInt(-1 << (bits - 1))
+		case f > 0:
+			T.SetUint(math.MaxUint64 >> (64 - bits))
+		}
+		return T.Interface(), nil
+	}
+	return nil, overflowError(f, targetType)
+}
+
+// parseError translates strconv range errors into overflow errors and wraps
+// any other parsing error so that it matches ErrParse
+func parseError(err error, targetType reflect.Type) error {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) && numErr.Err == strconv.ErrRange {
+		return overflowError(numErr.Num, targetType)
+	}
+	return &parseFailure{err: err}
+}
+
+// convertNumber converts a numeric value to another numeric type,
+// returning ErrOverflow if the value does not fit in the target type.
+// Floats are rounded to integers according to the engine's rounding mode, or rejected in strict mode if not exact.
+// NaN and infinite floats are converted to integers according to the engine's non-finite policy
+func (ce *ConverterEngine) convertNumber(S reflect.Value, targetType reflect.Type) (interface{}, error) {
+	T := reflect.New(targetType).Elem()
+	targetKind := targetType.Kind()
+
+	switch sourceKind := S.Kind(); {
+	case isInt(sourceKind):
+		i := S.Int()
+		switch {
+		case isInt(targetKind):
+			if T.OverflowInt(i) {
+				return nil, overflowError(i, targetType)
+			}
+			T.SetInt(i)
+		case isUint(targetKind):
+			if i < 0 || T.OverflowUint(uint64(i)) {
+				return nil, overflowError(i, targetType)
+			}
+			T.SetUint(uint64(i))
+		case isFloat(targetKind):
+			T.SetFloat(float64(i))
+		}
+	case isUint(sourceKind):
+		u := S.Uint()
+		switch {
+		case isInt(targetKind):
+			if u > math.MaxInt64 || T.OverflowInt(int64(u)) {
+				return nil, overflowError(u, targetType)
+			}
+			T.SetInt(int64(u))
+		case isUint(targetKind):
+			if T.OverflowUint(u) {
+				return nil, overflowError(u, targetType)
+			}
+			T.SetUint(u)
+		case isFloat(targetKind):
+			T.SetFloat(float64(u))
+		}
+	case isFloat(sourceKind):
+		f := S.Float()
+		if !isFloat(targetKind) && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			return ce.convertNonFinite(f, targetType)
+		}
+		if !isFloat(targetKind) {
+			var err error
+			if f, err = ce.roundFloat(f, targetType); err != nil {
+				return nil, err
+			}
+		}
+		switch {
+		case isInt(targetKind):
+			if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 || T.OverflowInt(int64(f)) {
+				return nil, overflowError(f, targetType)
+			}
+			T.SetInt(int64(f))
+		case isUint(targetKind):
+			if math.IsNaN(f) || f <= -1 || f >= math.MaxUint64 || T.OverflowUint(uint64(f)) {
+				return nil, overflowError(f, targetType)
+			}
+			T.SetUint(uint64(f))
+		case isFloat(targetKind):
+			if !math.IsInf(f, 0) && T.OverflowFloat(f) {
+				return nil, overflowError(f, targetType)
+			}
+			T.SetFloat(f)
+		}
+	}
+	return T.Interface(), nil
+}
+
+// convertComplex converts a real or complex number to a complex type,
+// returning ErrOverflow if the value does not fit in the target type
+func convertComplex(S reflect.Value, targetType reflect.Type) (interface{}, error) {
+	var c complex128
+	switch kind := S.Kind(); {
+	case isComplex(kind):
+		c = S.Complex()
+	case isInt(kind):
+		c = complex(float64(S.Int()), 0)
+	case isUint(kind):
+		c = complex(float64(S.Uint()), 0)
+	case isFloat(kind):
+		c = complex(S.Float(), 0)
+	}
+	T := reflect.New(targetType).Elem()
+	if T.OverflowComplex(c) {
+		return nil, overflowError(c, targetType)
+	}
+	T.SetComplex(c)
+	return T.Interface(), nil
+}
+
+// isZeroNumber returns true if the numeric value is zero
+func isZeroNumber(S reflect.Value) bool {
+	switch kind := S.Kind(); {
+	case isInt(kind):
+		return S.Int() == 0
+	case isUint(kind):
+		return S.Uint() == 0
+	}
+	return S.Float() == 0
+}
+
+// boolToNumber converts a boolean to 1 if true or 0 if false of the given numeric type
+func (ce *ConverterEngine) boolToNumber(b bool, targetType reflect.Type) (interface{}, error) {
+	var i int64
+	if b {
+		i = 1
+	}
+	return ce.convertNumber(reflect.ValueOf(i), targetType)
+}
@@ -0,0 +1,225 @@
+package elastic
+
+import (
+	"reflect"
+)
+
+// Option configures a conversion engine created with NewWithOptions
+type Option func(ce *ConverterEngine)
+
+// NewWithOptions creates a conversion engine with the default configuration, as New does,
+// and applies the given options to it in order
+func NewWithOptions(opts ...Option) *ConverterEngine {
+	ce := New()
+	for _, opt := range opts {
+		opt(ce)
+	}
+	return ce
+}
+
+// WithTagKey sets the struct tag key used to override the map key a struct field is converted to or from
+func WithTagKey(tagKey string) Option {
+	return func(ce *ConverterEngine) {
+		ce.TagKey = tagKey
+	}
+}
+
+// WithTimeLayout sets the layout used to convert time.Time to and from strings
+func WithTimeLayout(layout string) Option {
+	return func(ce *ConverterEngine) {
+		ce.TimeLayout = layout
+	}
+}
+
+// WithByteStringEncoding sets how byte slices are converted to and from strings
+func WithByteStringEncoding(encoding ByteStringEncoding) Option {
+	return func(ce *ConverterEngine) {
+		ce.ByteStringEncoding = encoding
+	}
+}
+
+// WithCharset sets the character set byte slices are decoded from and encoded to when converted to and from strings
+func WithCharset(charset *Charset) Option {
+	return func(ce *ConverterEngine) {
+		ce.Charset = charset
+	}
+}
+
+// WithValidateUTF8 makes converting byte slices that are not valid UTF-8 to strings fail with ErrInvalidUTF8
+func WithValidateUTF8() Option {
+	return func(ce *ConverterEngine) {
+		ce.ValidateUTF8 = true
+	}
+}
+
+// WithFloatFormat sets the format verb used to convert floats to strings, as in strconv.FormatFloat
+func WithFloatFormat(format byte) Option {
+	return func(ce *ConverterEngine) {
+		ce.FloatFormat = format
+	}
+}
+
+// WithFloatPrecision sets the precision used to convert floats to strings, as in strconv.FormatFloat
+func WithFloatPrecision(precision int) Option {
+	return func(ce *ConverterEngine) {
+		ce.FloatPrecision = precision
+	}
+}
+
+// WithSeparators sets the decimal and thousands separators expected when parsing strings into numbers.
+// A thousands separator of 0 means none
+func WithSeparators(decimal, thousands rune) Option {
+	return func(ce *ConverterEngine) {
+		ce.DecimalSeparator = decimal
+		ce.ThousandsSeparator = thousands
+	}
+}
+
+// WithBoolStrings sets the strings recognized as true and false when parsing booleans.
+// Booleans are formatted as the first of them
+func WithBoolStrings(trueStrings, falseStrings []string) Option {
+	return func(ce *ConverterEngine) {
+		ce.TrueStrings = append([]string(nil), trueStrings...)
+		ce.FalseStrings = append([]string(nil), falseStrings...)
+	}
+}
+
+// WithOnError sets what happens when the conversion of an element within a map, slice or struct fails
+func WithOnError(policy ErrorPolicy) Option {
+	return func(ce *ConverterEngine) {
+		ce.OnError = policy
+	}
+}
+
+// WithOnSkippedError sets the function called with every error skipped because of the UseZero error policy
+func WithOnSkippedError(f func(err error)) Option {
+	return func(ce *ConverterEngine) {
+		ce.OnSkippedError = f
+	}
+}
+
+// WithMaxDepth limits how deep the elements of nested maps, slices and structs can be
+func WithMaxDepth(maxDepth int) Option {
+	return func(ce *ConverterEngine) {
+		ce.MaxDepth = maxDepth
+	}
+}
+
+// WithRoundingMode sets how floats are converted to integers
+func WithRoundingMode(mode RoundingMode) Option {
+	return func(ce *ConverterEngine) {
+		ce.RoundingMode = mode
+	}
+}
+
+// WithStrict rejects floats with a fractional part converted to integers rather than rounding them
+func WithStrict() Option {
+	return func(ce *ConverterEngine) {
+		ce.Strict = true
+	}
+}
+
+// WithNonFinite sets how NaN and infinite floats are converted to integers
+func WithNonFinite(policy NonFinitePolicy) Option {
+	return func(ce *ConverterEngine) {
+		ce.NonFinite = policy
+	}
+}
+
+// WithUnwrapSingleElementSlices allows converting slices and arrays to scalar types by converting their only element
+func WithUnwrapSingleElementSlices() Option {
+	return func(ce *ConverterEngine) {
+		ce.UnwrapSingleElementSlices = true
+	}
+}
+
+// WithWrapScalarsIntoSlices allows converting scalar types to slices by wrapping them into a single element slice
+func WithWrapScalarsIntoSlices() Option {
+	return func(ce *ConverterEngine) {
+		ce.WrapScalarsIntoSlices = true
+	}
+}
+
+// WithSplitStrings allows converting strings to slices and arrays by splitting them on the list separator
+func WithSplitStrings() Option {
+	return func(ce *ConverterEngine) {
+		ce.SplitStrings = true
+	}
+}
+
+// WithJoinSlices allows converting slices and arrays to strings by joining their elements with the list separator
+func WithJoinSlices() Option {
+	return func(ce *ConverterEngine) {
+		ce.JoinSlices = true
+	}
+}
+
+// WithListSeparator sets the separator strings are split on and slice elements are joined with
+func WithListSeparator(separator string) Option {
+	return func(ce *ConverterEngine) {
+		ce.ListSeparator = separator
+	}
+}
+
+// WithoutStringParsing prevents strings from being parsed into numbers or bools
+func WithoutStringParsing() Option {
+	return func(ce *ConverterEngine) {
+		ce.DisableStringParsing = true
+	}
+}
+
+// WithOmitEmpty leaves empty fields out when converting structs to maps
+func WithOmitEmpty() Option {
+	return func(ce *ConverterEngine) {
+		ce.OmitEmpty = true
+	}
+}
+
+// WithCaseInsensitiveFields makes map keys match struct fields case-insensitively if no key matches exactly
+func WithCaseInsensitiveFields() Option {
+	return func(ce *ConverterEngine) {
+		ce.CaseInsensitiveFields = true
+	}
+}
+
+// WithErrorOnUnknownFields makes converting maps to structs fail if the map has keys that don't match any field
+func WithErrorOnUnknownFields() Option {
+	return func(ce *ConverterEngine) {
+		ce.ErrorOnUnknownFields = true
+	}
+}
+
+// WithErrorOnMissingFields makes converting maps to structs fail if the map has no key for some fields
+func WithErrorOnMissingFields() Option {
+	return func(ce *ConverterEngine) {
+		ce.ErrorOnMissingFields = true
+	}
+}
+
+// WithSortMapEntries sorts map entries by key when converting maps to slices of entries
+func WithSortMapEntries() Option {
+	return func(ce *ConverterEngine) {
+		ce.SortMapEntries = true
+	}
+}
+
+// WithTrace sets the function called at each decision point of a conversion
+func WithTrace(trace func(event string, source interface{}, targetType reflect.Type)) Option {
+	return func(ce *ConverterEngine) {
+		ce.Trace = trace
+	}
+}
+
+// WithFieldNameMapper sets the function that maps Go field names to the map keys they are converted to and from
+func WithFieldNameMapper(f func(goName string) string) Option {
+	return func(ce *ConverterEngine) {
+		ce.SetFieldNameMapper(f)
+	}
+}
+
+// WithFallbackConverter sets the conversion function invoked as a last resort, when no other conversion applies
+func WithFallbackConverter(f ConverterFunc) Option {
+	return func(ce *ConverterEngine) {
+		ce.SetFallbackConverter(f)
+	}
+}
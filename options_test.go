@@ -0,0 +1,53 @@
+package elastic_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+func TestNewWithOptions(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	// without options, the engine is configured as with New
+	t.Equals(elastic.New().TagKey, elastic.NewWithOptions().TagKey)
+	t.Equals(3, elastic.NewWithOptions().MustConvert(3.7, reflect.TypeOf(0)))
+
+	engine := elastic.NewWithOptions(
+		elastic.WithRoundingMode(elastic.Round),
+		elastic.WithTagKey("json"),
+		elastic.WithFloatPrecision(2),
+		elastic.WithFloatFormat('f'),
+		elastic.WithSeparators(',', '.'),
+		elastic.WithBoolStrings([]string{"yes"}, []string{"no"}),
+		elastic.WithByteStringEncoding(elastic.Hex),
+	)
+	t.Equals(4, engine.MustConvert(3.7, reflect.TypeOf(0)))
+	t.Equals("3.14", engine.MustConvert(3.14159, reflect.TypeOf("")))
+	t.Equals(1234.5, engine.MustConvert("1.234,5", reflect.TypeOf(0.0)))
+	t.Equals("yes", engine.MustConvert(true, reflect.TypeOf("")))
+	t.Equals("cafe", engine.MustConvert([]byte{0xca, 0xfe}, reflect.TypeOf("")))
+
+	var ts TaggedStruct
+	t.Ok(engine.Set(&ts, map[string]interface{}{"name": "ann"}))
+	t.Equals("ann", ts.UserName)
+
+	engine = elastic.NewWithOptions(elastic.WithStrict())
+	_, err := engine.Convert(3.7, reflect.TypeOf(0))
+	t.Assert(errors.Is(err, elastic.ErrPrecisionLoss), "Expected ErrPrecisionLoss, got %v", err)
+
+	// options are applied in order
+	engine = elastic.NewWithOptions(elastic.WithSplitStrings(), elastic.WithListSeparator(";"), elastic.WithListSeparator("|"))
+	t.Equals([]int{1, 2, 3}, engine.MustConvert("1|2|3", reflect.TypeOf([]int{})))
+
+	engine = elastic.NewWithOptions(elastic.WithFieldNameMapper(elastic.SnakeCase), elastic.WithErrorOnUnknownFields())
+	var user struct{ UserName string }
+	t.Ok(engine.Set(&user, map[string]interface{}{"user_name": "bob"}))
+	t.Equals("bob", user.UserName)
+	err = engine.Set(&user, map[string]interface{}{"UserName": "bob"})
+	t.Assert(errors.Is(err, elastic.ErrUnknownFields), "Expected ErrUnknownFields, got %v", err)
+}
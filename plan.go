@@ -0,0 +1,102 @@
+package elastic
+
+import (
+	"database/sql/driver"
+	"encoding"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	converterToType   = reflect.TypeOf((*ConverterTo)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	valuerType        = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// typePair is the key used to cache conversion plans
+type typePair struct {
+	source reflect.Type
+	target reflect.Type
+}
+
+// conversionPlan caches the decisions that only depend on the source and target types of a conversion,
+// such as which converters apply or which interfaces are implemented, so that repeated conversions
+// between the same types skip these lookups
+type conversionPlan struct {
+	conversion          func(source interface{}) (interface{}, error)
+	sourceConverters    []ConverterFunc
+	converterTo         bool
+	converterFrom       bool
+	targetConverters    []ConverterFunc
+	interfaceConverters []ConverterFunc
+	kindConverters      []ConverterFunc
+	implementsTarget    bool
+	boxesSource         bool
+	builtinConverters   []builtinConverterFunc
+	textMarshaler       bool
+	textUnmarshaler     bool
+	valuer              bool
+	scanner             bool
+	keyValueSetter      bool
+	stringer            bool
+}
+
+// newPlanCache returns an empty conversion plan cache
+func newPlanCache() *sync.Map {
+	return new(sync.Map)
+}
+
+// invalidatePlans discards all cached conversion plans. It must be called whenever converters change
+func (ce *ConverterEngine) invalidatePlans() {
+	ce.plans = newPlanCache()
+}
+
+// plan returns the conversion plan for the given pair of types, building it if it is not cached yet
+func (ce *ConverterEngine) plan(sourceType, targetType reflect.Type) *conversionPlan {
+	key := typePair{source: sourceType, target: targetType}
+	if p, ok := ce.plans.Load(key); ok {
+		return p.(*conversionPlan)
+	}
+
+	targetPtrType := reflect.PtrTo(targetType)
+	p := &conversionPlan{
+		conversion:       ce.conversions[[2]reflect.Type{sourceType, targetType}],
+		sourceConverters: ce.sourceConverters[sourceType],
+		converterTo:      sourceType.Implements(converterToType),
+		converterFrom:    targetPtrType.Implements(converterFromType),
+		targetConverters: ce.targetConverters[targetType],
+		kindConverters:   ce.kindConverters[sourceType.Kind()],
+		textMarshaler:    sourceType.Implements(textMarshalerType) && targetType.Kind() == reflect.String,
+		textUnmarshaler:  targetPtrType.Implements(textUnmarshalerType) && (sourceType.Kind() == reflect.String || isBytes(sourceType)),
+		valuer:           sourceType.Implements(valuerType),
+		scanner:          targetPtrType.Implements(scannerType),
+		keyValueSetter:   targetPtrType.Implements(keyValueSetterType) && sourceType.Kind() == reflect.Map,
+		stringer:         sourceType.Implements(stringerType) && targetType.Kind() == reflect.String,
+		implementsTarget: targetType.Kind() == reflect.Interface && sourceType.Implements(targetType),
+	}
+	// empty interfaces box any source as is, unless converters were registered for them explicitly
+	p.boxesSource = targetType.Kind() == reflect.Interface && targetType.NumMethod() == 0 && p.conversion == nil && len(p.targetConverters) == 0
+	for _, converter := range ce.interfaceConverters {
+		if sourceType.Implements(converter.interfaceType) {
+			p.interfaceConverters = append(p.interfaceConverters, converter.f)
+		}
+	}
+	for _, converter := range []builtinConverterFunc{builtinSourceConverters[sourceType], builtinTargetConverters[targetType]} {
+		if converter != nil {
+			p.builtinConverters = append(p.builtinConverters, converter)
+		}
+	}
+
+	ce.plans.Store(key, p)
+	return p
+}
+
+// custom returns true if the conversion is handled by converters or interfaces rather than by the
+// default conversion of the source and target kinds
+func (p *conversionPlan) custom() bool {
+	return p.conversion != nil || len(p.sourceConverters) > 0 || p.converterTo || p.converterFrom ||
+		len(p.targetConverters) > 0 || len(p.interfaceConverters) > 0 || len(p.kindConverters) > 0 || len(p.builtinConverters) > 0 ||
+		p.textUnmarshaler || p.valuer || p.scanner || p.keyValueSetter
+}
@@ -0,0 +1,17 @@
+package elastic
+
+import (
+	"database/sql"
+	"reflect"
+)
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// scan builds a target implementing sql.Scanner by scanning the source into it
+func scan(source interface{}, targetType reflect.Type) (interface{}, error) {
+	T := reflect.New(targetType)
+	if err := T.Interface().(sql.Scanner).Scan(source); err != nil {
+		return nil, err
+	}
+	return T.Elem().Interface(), nil
+}
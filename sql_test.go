@@ -0,0 +1,51 @@
+package elastic_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+func TestSQL(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	var ns sql.NullString
+	err := elastic.Set(&ns, "hello")
+	t.Ok(err)
+	t.Equals(sql.NullString{String: "hello", Valid: true}, ns)
+
+	err = elastic.Set(&ns, nil)
+	t.Ok(err)
+	t.Equals(sql.NullString{}, ns)
+
+	var ni sql.NullInt64
+	err = elastic.Set(&ni, "42")
+	t.Ok(err)
+	t.Equals(sql.NullInt64{Int64: 42, Valid: true}, ni)
+
+	err = elastic.Set(&ni, "XYZ")
+	t.MustFail(err, "Expected scanning to fail")
+
+	var i int
+	err = elastic.Set(&i, sql.NullInt64{Int64: 42, Valid: true})
+	t.Ok(err)
+	t.Equals(42, i)
+
+	err = elastic.Set(&i, sql.NullInt64{})
+	t.Ok(err)
+	t.Equals(0, i)
+
+	var s string
+	err = elastic.Set(&s, sql.NullFloat64{Float64: 1.5, Valid: true})
+	t.Ok(err)
+	t.Equals("1.5", s)
+
+	// from one Valuer to a Scanner
+	var nf sql.NullFloat64
+	err = elastic.Set(&nf, sql.NullInt64{Int64: 7, Valid: true})
+	t.Ok(err)
+	t.Equals(sql.NullFloat64{Float64: 7, Valid: true}, nf)
+}
@@ -0,0 +1,561 @@
+package elastic
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// DefaultTagKey is the struct tag key used by default to customize how fields map to map keys
+const DefaultTagKey = "elastic"
+
+// ErrUnknownFields is returned when converting a map to a struct with the engine's ErrorOnUnknownFields option set
+// and some keys of the map don't match any field of the struct. The error message lists the unknown keys
+var ErrUnknownFields = errors.New("Unknown fields")
+
+// ErrMissingFields is returned when converting a map to a struct and the map has no key for some required fields,
+// that is, fields with the required tag option or any field if the engine's ErrorOnMissingFields option is set.
+// The error message lists the keys of the missing fields
+var ErrMissingFields = errors.New("Missing fields")
+
+// structField identifies a field of a struct type, used to register field converters
+type structField struct {
+	structType reflect.Type
+	name       string
+}
+
+// AddFieldConverter adds a conversion function to the engine that converts the values of the given struct field,
+// by Go field name, when converting into the given struct type. It overrides the default conversion of the field,
+// unless it returns ErrNoConversionAvailable
+func (ce *ConverterEngine) AddFieldConverter(structType reflect.Type, fieldName string, f ConverterFunc) {
+	if structType.Kind() != reflect.Struct {
+		panic("type must be a struct")
+	}
+	if _, ok := structType.FieldByName(fieldName); !ok {
+		panic(fmt.Sprintf("field %s not found in %s", fieldName, structType))
+	}
+	if ce.fieldConverters == nil {
+		ce.fieldConverters = make(map[structField]ConverterFunc)
+	}
+	ce.fieldConverters[structField{structType: structType, name: fieldName}] = f
+}
+
+// RemoveFieldConverter removes the conversion function registered for the given struct field
+func (ce *ConverterEngine) RemoveFieldConverter(structType reflect.Type, fieldName string) {
+	delete(ce.fieldConverters, structField{structType: structType, name: fieldName})
+}
+
+// SetFieldNameMapper sets a function that maps Go field names to the map keys they are converted to and from,
+// such as SnakeCase, so that fields don't need to be tagged one by one. Keys given in struct tags are used as is.
+// Pass nil to remove it
+func (ce *ConverterEngine) SetFieldNameMapper(f func(goName string) string) {
+	ce.fieldNameMapper = f
+}
+
+// SnakeCase maps a Go field name to snake_case, such as UserName to user_name or HTTPServer to http_server.
+// It can be passed to SetFieldNameMapper
+func SnakeCase(goName string) string {
+	runes := []rune(goName)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// convertField converts a value into the given field of a struct type, using the field converter
+// registered for it or the time layout given in its tag, if any
+func (ce *ConverterEngine) convertField(c *conversion, structType reflect.Type, field reflect.StructField, element string, source interface{}) (interface{}, error) {
+	converter := ce.fieldConverters[structField{structType: structType, name: field.Name}]
+	layout, hasLayout := ce.tagOption(field, "layout")
+	if converter == nil && !hasLayout {
+		return ce.convertElement(c, element, source, field.Type)
+	}
+	return ce.convertElementWith(c, element, field.Type, func() (interface{}, error) {
+		if converter != nil {
+			result, err := converter(source, field.Type)
+			if err == nil {
+				return ce.convert(c, result, field.Type)
+			}
+			if err != ErrNoConversionAvailable {
+				return nil, err
+			}
+		}
+		if hasLayout {
+			result, err := parseTimeLayout(source, field.Type, layout)
+			if err == nil {
+				return ce.convert(c, result, field.Type)
+			}
+			if err != ErrNoConversionAvailable {
+				return nil, err
+			}
+		}
+		return ce.convert(c, source, field.Type)
+	})
+}
+
+// formatField returns the value of a struct field formatted with the time layout given in its tag, if any,
+// when it is converted to a string type, so that the field converts back. Otherwise it returns the value as is
+func (ce *ConverterEngine) formatField(field reflect.StructField, value interface{}, targetType reflect.Type) interface{} {
+	layout, hasLayout := ce.tagOption(field, "layout")
+	if !hasLayout || targetType.Kind() != reflect.String {
+		return value
+	}
+	if formatted, err := formatTimeLayout(value, layout); err == nil {
+		return formatted
+	}
+	return value
+}
+
+// isExported returns true if the given struct field is exported
+func isExported(field reflect.StructField) bool {
+	return field.PkgPath == ""
+}
+
+// fieldKey returns the key a struct field is known by when converting to or from a map,
+// which is the field name, as mapped by the field name mapper if any, unless overridden by the struct tag.
+// Returns false if the field must be skipped
+func (ce *ConverterEngine) fieldKey(field reflect.StructField) (string, bool) {
+	if !isExported(field) {
+		return "", false
+	}
+	name := strings.SplitN(field.Tag.Get(ce.TagKey), ",", 2)[0]
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		if ce.fieldNameMapper != nil {
+			return ce.fieldNameMapper(field.Name), true
+		}
+		return field.Name, true
+	}
+	return name, true
+}
+
+// mappedField is a struct field converted to or from a map entry, along with the key it is known by.
+// Its Index holds the path to the field from the outer struct, as fields of embedded structs are promoted
+type mappedField struct {
+	reflect.StructField
+	key string
+}
+
+// mappedFields returns the fields of a struct type that are converted to and from map entries.
+// Like in encoding/json, the fields of embedded structs without a key name in their tag are promoted as if they
+// were fields of the outer struct. On key collisions, shallower fields take precedence over deeper ones,
+// and fields declared first over the rest at the same depth
+func (ce *ConverterEngine) mappedFields(structType reflect.Type) []mappedField {
+	var fields []mappedField
+	depths := make(map[string]int)
+	ce.collectMappedFields(structType, nil, make(map[reflect.Type]bool), depths, &fields)
+
+	promoted := fields[:0]
+	for _, field := range fields {
+		if depths[field.key] == len(field.Index) {
+			promoted = append(promoted, field)
+			depths[field.key] = -1 // the first field at the shallowest depth wins
+		}
+	}
+	return promoted
+}
+
+// hasMappedFields returns true if values of the given struct type convert to and from maps and slices field by field,
+// that is, the engine has no built-in conversion for the type and it has some fields to map
+func (ce *ConverterEngine) hasMappedFields(structType reflect.Type) bool {
+	return !hasBuiltinConverter(structType) && len(ce.mappedFields(structType)) > 0
+}
+
+// collectMappedFields appends the fields of the given struct type to fields, recursing into embedded structs,
+// and records in depths the shallowest depth each key is found at
+func (ce *ConverterEngine) collectMappedFields(structType reflect.Type, index []int, visiting map[reflect.Type]bool, depths map[string]int, fields *[]mappedField) {
+	if visiting[structType] {
+		return // embedded struct pointers may refer back to an outer struct
+	}
+	visiting[structType] = true
+	defer delete(visiting, structType)
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		field.Index = append(append([]int(nil), index...), i)
+		if embedded := embeddedStruct(field); embedded != nil && strings.SplitN(field.Tag.Get(ce.TagKey), ",", 2)[0] == "" {
+			ce.collectMappedFields(embedded, field.Index, visiting, depths, fields)
+			continue
+		}
+		key, ok := ce.fieldKey(field)
+		if !ok {
+			continue
+		}
+		if depth, found := depths[key]; !found || len(field.Index) < depth {
+			depths[key] = len(field.Index)
+		}
+		*fields = append(*fields, mappedField{StructField: field, key: key})
+	}
+}
+
+// embeddedStruct returns the struct type of an embedded struct field whose fields are promoted, or nil if the field
+// is not one. Embedded pointers to unexported struct types are not promoted, as they can't be allocated
+func embeddedStruct(field reflect.StructField) reflect.Type {
+	if !field.Anonymous {
+		return nil
+	}
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		if !isExported(field) {
+			return nil
+		}
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+// fieldByIndex returns the field of the given struct value at the given index path,
+// allocating the nil embedded struct pointers found along the way
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// tagOption looks up an option in the struct tag of the given field, after the key name, such as omitempty
+// in `elastic:"name,omitempty"`. Options with a value return it, such as layout in `elastic:"date,layout=2006-01-02"`.
+// Since layouts may contain commas, the layout option takes the rest of the tag and must go last
+func (ce *ConverterEngine) tagOption(field reflect.StructField, option string) (string, bool) {
+	_, options, _ := strings.Cut(field.Tag.Get(ce.TagKey), ",")
+	for options != "" {
+		var opt string
+		if strings.HasPrefix(options, "layout=") {
+			opt, options = options, ""
+		} else {
+			opt, options, _ = strings.Cut(options, ",")
+		}
+		name, value, _ := strings.Cut(opt, "=")
+		if name == option {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// omitsEmpty returns true if the given field must be left out when converting its struct to a map
+// while it is empty, because of the engine's OmitEmpty option or the omitempty option of its tag
+func (ce *ConverterEngine) omitsEmpty(field reflect.StructField) bool {
+	if ce.OmitEmpty {
+		return true
+	}
+	_, ok := ce.tagOption(field, "omitempty")
+	return ok
+}
+
+// requiresField returns true if the given field must have a matching key when converting a map to its struct,
+// because of the engine's ErrorOnMissingFields option or the required option of its tag
+func (ce *ConverterEngine) requiresField(field reflect.StructField) bool {
+	if ce.ErrorOnMissingFields {
+		return true
+	}
+	_, ok := ce.tagOption(field, "required")
+	return ok
+}
+
+// requiredFields collects the required fields missing from the sources of a struct and the fields they assign,
+// so that a struct layered out of several sources is only checked for missing fields once all of them are applied
+type requiredFields struct {
+	missing  []string        // paths of the required fields some source has no key for, in order
+	assigned map[string]bool // paths of the fields some source assigned
+}
+
+// check returns ErrMissingFields naming the required fields that no source assigned
+func (r *requiredFields) check() error {
+	var missing []string
+	for _, path := range r.missing {
+		if !r.assigned[path] {
+			r.assigned[path] = true // so that each field is named once
+			missing = append(missing, path)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingFields, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// isEmptyValue returns true if the value is empty, that is, its zero value or an empty slice, map or array.
+// Non-nil pointers are never empty, even if they point to a zero value
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// convertStruct attempts to convert a struct to another type of struct by matching field names
+// Fields only present in the source are ignored and fields only present in the target are left
+// to their zero value
+func (ce *ConverterEngine) convertStruct(c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	T := reflect.New(targetType).Elem()
+	if err := ce.fillStruct(c, reflect.ValueOf(source), T, false); err != nil {
+		return nil, err
+	}
+	return T.Interface(), nil
+}
+
+// fillStruct sets the fields of the given target struct out of the fields of the source struct with the same name,
+// leaving the rest untouched. When merging, zero source fields are considered to have no value and are skipped
+func (ce *ConverterEngine) fillStruct(c *conversion, S, T reflect.Value, merging bool) error {
+	sourceType := S.Type()
+	targetType := T.Type()
+
+	for i := 0; i < targetType.NumField(); i++ {
+		targetField := targetType.Field(i)
+		if !isExported(targetField) {
+			continue
+		}
+		sourceField, ok := sourceType.FieldByName(targetField.Name)
+		if !ok || !isExported(sourceField) {
+			continue
+		}
+		sourceValue, err := S.FieldByIndexErr(sourceField.Index)
+		if err != nil {
+			continue // fields of nil embedded struct pointers have no value
+		}
+		if merging && sourceValue.IsZero() {
+			continue
+		}
+		if err := ce.setField(c, T, targetField, targetField.Name, sourceValue.Interface(), merging); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setField converts the source value into the given field of the target struct, or merges it into the field's
+// existing value if merging, unless the field has a field converter or a time layout
+func (ce *ConverterEngine) setField(c *conversion, T reflect.Value, field reflect.StructField, element string, source interface{}, merging bool) error {
+	var value interface{}
+	var err error
+	_, hasLayout := ce.tagOption(field, "layout")
+	if merging && !hasLayout && ce.fieldConverters[structField{structType: T.Type(), name: field.Name}] == nil {
+		value, err = ce.mergeElement(c, element, fieldByIndex(T, field.Index), source)
+	} else {
+		value, err = ce.convertField(c, T.Type(), field, element, source)
+	}
+	if err != nil {
+		return err
+	}
+	fieldByIndex(T, field.Index).Set(valueOf(value, field.Type))
+	return nil
+}
+
+// convertMapToStruct attempts to populate a struct out of a map by looking up each exported field key
+// in the map, including those promoted from embedded structs. Missing keys leave the field to its zero value,
+// unless the field is required, and keys that don't match any field are ignored, unless the engine's
+// ErrorOnUnknownFields option is set
+func (ce *ConverterEngine) convertMapToStruct(c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	T := reflect.New(targetType).Elem()
+	if err := ce.fillStructFromMap(c, reflect.ValueOf(source), T, false); err != nil {
+		return nil, err
+	}
+	return T.Interface(), nil
+}
+
+// fillStructFromMap sets the fields of the given target struct out of the entries of the source map,
+// leaving the fields without a matching key untouched. Keys are matched case-insensitively if no key matches
+// exactly and the engine's CaseInsensitiveFields option is set. Fails with ErrUnknownFields if some keys
+// don't match any field and the engine's ErrorOnUnknownFields option is set, or with ErrMissingFields
+// if there is no key for some required fields, unless the conversion collects them to check later.
+// When merging, fields are not required and nil values are skipped
+func (ce *ConverterEngine) fillStructFromMap(c *conversion, S, T reflect.Value, merging bool) error {
+	keyType := S.Type().Key()
+	targetType := T.Type()
+	var foldedKeys []reflect.Value // keys of the source map, looked up case-insensitively
+	var missing []string           // keys of the required fields not found in the source map
+	var matched map[interface{}]bool
+	if ce.ErrorOnUnknownFields {
+		matched = make(map[interface{}]bool, S.Len())
+	}
+
+	for _, targetField := range ce.mappedFields(targetType) {
+		key, err := ce.convert(c, targetField.key, keyType)
+		if err != nil {
+			// this field name can't be represented as a key of this map
+			if !merging && ce.requiresField(targetField.StructField) {
+				missing = append(missing, targetField.key)
+			}
+			continue
+		}
+		mapKey := valueOf(key, keyType)
+		mapValue := S.MapIndex(mapKey)
+		if !mapValue.IsValid() && ce.CaseInsensitiveFields && keyType.Kind() == reflect.String {
+			if foldedKeys == nil {
+				foldedKeys = S.MapKeys()
+				sortKeys(foldedKeys) // so that the same key wins if several match
+			}
+			for _, k := range foldedKeys {
+				if strings.EqualFold(k.String(), targetField.key) {
+					mapKey, mapValue = k, S.MapIndex(k)
+					break
+				}
+			}
+		}
+		if !mapValue.IsValid() {
+			if !merging && ce.requiresField(targetField.StructField) {
+				missing = append(missing, targetField.key)
+			}
+			continue
+		}
+		if matched != nil {
+			matched[mapKey.Interface()] = true
+		}
+		if merging && isNil(mapValue) {
+			continue
+		}
+		if err := ce.setField(c, T, targetField.StructField, targetField.key, mapValue.Interface(), merging); err != nil {
+			return err
+		}
+		if c.required != nil {
+			c.required.assigned[joinPath(append(c.path, targetField.key))] = true
+		}
+	}
+	if len(missing) > 0 && c.required != nil {
+		for _, key := range missing {
+			c.required.missing = append(c.required.missing, joinPath(append(c.path, key)))
+		}
+	} else if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingFields, strings.Join(missing, ", "))
+	}
+	if matched != nil && len(matched) < S.Len() {
+		return unknownFieldsError(S, matched)
+	}
+	return nil
+}
+
+// unknownFieldsError returns ErrUnknownFields listing the keys of the source map that are not matched, in order
+func unknownFieldsError(S reflect.Value, matched map[interface{}]bool) error {
+	keys := S.MapKeys()
+	sortKeys(keys)
+	var unknown []string
+	for _, k := range keys {
+		if !matched[k.Interface()] {
+			unknown = append(unknown, fmt.Sprint(k.Interface()))
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownFields, strings.Join(unknown, ", "))
+}
+
+// convertStructToMap attempts to convert a struct into a map keyed by field key. The map key must be of string kind.
+// The fields of embedded structs are promoted to keys of the map, while when the map element type is an interface,
+// other nested structs are recursively converted into maps of the same type.
+// Empty fields are left out if the engine's OmitEmpty option or their omitempty tag option is set.
+// When merging, zero fields are considered to have no value and are left out too, also from nested maps
+func (ce *ConverterEngine) convertStructToMap(c *conversion, source interface{}, targetType reflect.Type, merging bool) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	T := reflect.MakeMap(targetType)
+	sourceType := S.Type()
+	keyType := targetType.Key()
+	elemType := targetType.Elem()
+
+	for _, field := range ce.mappedFields(sourceType) {
+		name := field.key
+		fieldValue, err := S.FieldByIndexErr(field.Index)
+		if err != nil {
+			continue // fields of nil embedded struct pointers are left out
+		}
+		if ce.omitsEmpty(field.StructField) && isEmptyValue(fieldValue) || merging && fieldValue.IsZero() {
+			continue
+		}
+		var value interface{}
+		switch {
+		case elemType.Kind() != reflect.Interface || fieldValue.Kind() != reflect.Struct || !ce.hasMappedFields(fieldValue.Type()):
+			value, err = ce.convertElement(c, name, ce.formatField(field.StructField, fieldValue.Interface(), elemType), elemType)
+		case merging && !ce.plan(fieldValue.Type(), targetType).custom():
+			// nested structs become nested maps, leaving their zero fields out too
+			value, err = ce.convertElementWith(c, name, targetType, func() (interface{}, error) {
+				return ce.convertStructToMap(c, fieldValue.Interface(), targetType, true)
+			})
+		default:
+			value, err = ce.convertElement(c, name, fieldValue.Interface(), targetType) // nested structs become nested maps
+		}
+		if err != nil {
+			return nil, err
+		}
+		T.SetMapIndex(reflect.ValueOf(name).Convert(keyType), valueOf(value, elemType))
+	}
+	return T.Interface(), nil
+}
+
+// positionalFields returns the indexes of the fields of a struct type that take part in positional
+// conversions, that is, its exported fields that are not skipped, in declaration order
+func (ce *ConverterEngine) positionalFields(structType reflect.Type) []int {
+	var fields []int
+	for i := 0; i < structType.NumField(); i++ {
+		if _, ok := ce.fieldKey(structType.Field(i)); ok {
+			fields = append(fields, i)
+		}
+	}
+	return fields
+}
+
+// convertSliceToStruct attempts to populate a struct out of a slice or array, assigning each element
+// to the next exported field in declaration order. Fields without a matching element are left to their zero value
+func (ce *ConverterEngine) convertSliceToStruct(c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	T := reflect.New(targetType).Elem()
+	fields := ce.positionalFields(targetType)
+
+	if S.Len() > len(fields) {
+		return nil, fmt.Errorf("%w: cannot convert %d elements into %d fields of %s", ErrLengthMismatch, S.Len(), len(fields), targetType)
+	}
+	for i := 0; i < S.Len(); i++ {
+		targetField := targetType.Field(fields[i])
+		value, err := ce.convertField(c, targetType, targetField, targetField.Name, S.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		T.Field(fields[i]).Set(valueOf(value, targetField.Type))
+	}
+	return T.Interface(), nil
+}
+
+// convertStructToSlice attempts to convert a struct into a slice or array, emitting its exported fields
+// in declaration order. Fixed-size array targets must have as many elements as fields
+func (ce *ConverterEngine) convertStructToSlice(c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	fields := ce.positionalFields(S.Type())
+	var T reflect.Value
+	if targetType.Kind() == reflect.Array {
+		if len(fields) != targetType.Len() {
+			return nil, fmt.Errorf("%w: cannot convert %d fields into %s", ErrLengthMismatch, len(fields), targetType)
+		}
+		T = reflect.New(targetType).Elem()
+	} else {
+		T = reflect.MakeSlice(targetType, len(fields), len(fields))
+	}
+	targetElementType := targetType.Elem()
+
+	for i, field := range fields {
+		value, err := ce.convertElement(c, S.Type().Field(field).Name, S.Field(field).Interface(), targetElementType)
+		if err != nil {
+			return nil, err
+		}
+		T.Index(i).Set(valueOf(value, targetElementType))
+	}
+	return T.Interface(), nil
+}
@@ -0,0 +1,33 @@
+package elastic
+
+import (
+	"encoding"
+	"reflect"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// marshalText converts a source implementing encoding.TextMarshaler into a string
+func marshalText(source interface{}, targetType reflect.Type) (interface{}, error) {
+	b, err := source.(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return kind2Exact(string(b), targetType), nil
+}
+
+// unmarshalText builds a target implementing encoding.TextUnmarshaler out of a string or byte slice source
+func unmarshalText(source interface{}, targetType reflect.Type) (interface{}, error) {
+	T := reflect.New(targetType)
+	S := reflect.ValueOf(source)
+	var text []byte
+	if S.Kind() == reflect.String {
+		text = []byte(S.String())
+	} else {
+		text = S.Bytes()
+	}
+	if err := T.Interface().(encoding.TextUnmarshaler).UnmarshalText(text); err != nil {
+		return nil, err
+	}
+	return T.Elem().Interface(), nil
+}
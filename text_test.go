@@ -0,0 +1,79 @@
+package elastic_test
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+type Level int
+
+func (l Level) MarshalText() ([]byte, error) {
+	switch l {
+	case 0:
+		return []byte("low"), nil
+	case 1:
+		return []byte("high"), nil
+	}
+	return nil, errors.New("invalid level")
+}
+
+func (l *Level) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "low":
+		*l = 0
+	case "high":
+		*l = 1
+	default:
+		return errors.New("invalid level")
+	}
+	return nil
+}
+
+func TestTextMarshaler(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	s, err := elastic.Convert(Level(1), reflect.TypeOf(""))
+	t.Ok(err)
+	t.Equals("high", s)
+
+	s, err = elastic.Convert(Level(1), reflect.TypeOf(StringAlias("")))
+	t.Ok(err)
+	t.Equals(StringAlias("high"), s)
+
+	_, err = elastic.Convert(Level(5), reflect.TypeOf(""))
+	t.MustFail(err, "Expected marshaling to fail")
+
+	var l Level
+	err = elastic.Set(&l, "HIGH")
+	t.Ok(err)
+	t.Equals(Level(1), l)
+
+	err = elastic.Set(&l, []byte("low"))
+	t.Ok(err)
+	t.Equals(Level(0), l)
+
+	err = elastic.Set(&l, "medium")
+	t.MustFail(err, "Expected unmarshaling to fail")
+
+	var levels []Level
+	err = elastic.Set(&levels, []string{"low", "high"})
+	t.Ok(err)
+	t.Equals([]Level{0, 1}, levels)
+
+	// Level is still a number
+	err = elastic.Set(&l, 1)
+	t.Ok(err)
+	t.Equals(Level(1), l)
+
+	var ip net.IP
+	err = elastic.Set(&ip, "10.0.0.1")
+	t.Ok(err)
+	t.Equals(net.ParseIP("10.0.0.1"), ip)
+}
@@ -0,0 +1,114 @@
+package elastic
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// DefaultTimeLayout is the layout used by default to convert time.Time to and from strings
+const DefaultTimeLayout = time.RFC3339
+
+var timeType = reflect.TypeOf(time.Time{})
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func init() {
+	builtinSourceConverters[timeType] = convertFromTime
+	builtinTargetConverters[timeType] = convertToTime
+	builtinSourceConverters[durationType] = convertFromDuration
+	builtinTargetConverters[durationType] = convertToDuration
+}
+
+// convertFromTime converts a time.Time to a string using the engine's time layout
+// or to a number representing Unix seconds
+func convertFromTime(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	t := source.(time.Time)
+	switch kind := targetType.Kind(); {
+	case kind == reflect.String:
+		return t.Format(ce.TimeLayout), nil
+	case isInt(kind) || isUint(kind):
+		return t.Unix(), nil
+	case isFloat(kind):
+		return float64(t.UnixNano()) / float64(time.Second), nil
+	}
+	return nil, ErrNoConversionAvailable
+}
+
+// convertToTime converts a string to time.Time using the engine's time layout
+// or a number representing Unix seconds
+func convertToTime(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	switch kind := S.Kind(); {
+	case kind == reflect.String:
+		t, err := time.Parse(ce.TimeLayout, S.String())
+		if err != nil {
+			return nil, parseError(err, targetType)
+		}
+		return t, nil
+	case isInt(kind):
+		return time.Unix(S.Int(), 0), nil
+	case isUint(kind):
+		if S.Uint() > math.MaxInt64 {
+			return nil, overflowError(S.Uint(), targetType)
+		}
+		return time.Unix(int64(S.Uint()), 0), nil
+	case isFloat(kind):
+		sec, frac := math.Modf(S.Float())
+		return time.Unix(int64(sec), int64(frac*float64(time.Second))), nil
+	}
+	return nil, ErrNoConversionAvailable
+}
+
+// convertFromDuration converts a time.Duration to a string such as "1h30m0s".
+// Conversion to numbers is left to the default numeric conversion, yielding nanoseconds
+func convertFromDuration(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	if targetType.Kind() == reflect.String {
+		return source.(time.Duration).String(), nil
+	}
+	return nil, ErrNoConversionAvailable
+}
+
+// convertToDuration parses a string such as "1h30m" into a time.Duration. Strings containing plain integers
+// and numeric sources are interpreted as nanoseconds
+func convertToDuration(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	if S.Kind() != reflect.String {
+		return nil, ErrNoConversionAvailable
+	}
+	d, err := time.ParseDuration(S.String())
+	if err != nil {
+		if _, perr := strconv.ParseInt(S.String(), integerBase(S.String()), 64); perr == nil {
+			return nil, ErrNoConversionAvailable // let the default numeric parsing take care of it
+		}
+		return nil, parseError(err, targetType)
+	}
+	return d, nil
+}
+
+// parseTimeLayout parses a string source into a time.Time, or a pointer to it, using the given layout
+func parseTimeLayout(source interface{}, targetType reflect.Type, layout string) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	if S.Kind() != reflect.String || (targetType != timeType && targetType != reflect.PtrTo(timeType)) {
+		return nil, ErrNoConversionAvailable
+	}
+	t, err := time.Parse(layout, S.String())
+	if err != nil {
+		return nil, parseError(fmt.Errorf("cannot parse %q with layout %q: %w", S.String(), layout, err), targetType)
+	}
+	return t, nil
+}
+
+// formatTimeLayout formats a time.Time source, or a non-nil pointer to it, as a string using the given layout
+func formatTimeLayout(source interface{}, layout string) (interface{}, error) {
+	switch t := source.(type) {
+	case time.Time:
+		return t.Format(layout), nil
+	case *time.Time:
+		if t != nil {
+			return t.Format(layout), nil
+		}
+	}
+	return nil, ErrNoConversionAvailable
+}
@@ -0,0 +1,129 @@
+package elastic
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+var urlType = reflect.TypeOf(url.URL{})
+var valuesType = reflect.TypeOf(url.Values{})
+
+func init() {
+	builtinSourceConverters[urlType] = convertFromURL
+	builtinTargetConverters[urlType] = convertToURL
+	builtinSourceConverters[valuesType] = convertFromValues
+	builtinTargetConverters[valuesType] = convertToValues
+}
+
+// convertFromURL converts a url.URL to a string. *url.URL sources are dereferenced or converted through String()
+func convertFromURL(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	if targetType.Kind() != reflect.String {
+		return nil, ErrNoConversionAvailable
+	}
+	u := source.(url.URL)
+	return u.String(), nil
+}
+
+// convertToURL parses a url.URL out of a string. *url.URL targets are allocated to hold it
+func convertToURL(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	if S.Kind() != reflect.String {
+		return nil, ErrNoConversionAvailable
+	}
+	u, err := url.Parse(S.String())
+	if err != nil {
+		return nil, parseError(err, targetType)
+	}
+	return *u, nil
+}
+
+// isMultiValued returns true if a field of the given type holds several form values, that is, if it is
+// a slice or an array other than a byte slice, possibly behind a pointer
+func isMultiValued(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return isList(t.Kind()) && !isBytes(t) && !hasBuiltinConverter(t)
+}
+
+// convertFromValues converts url.Values, such as a parsed form, to a struct through a map holding every key,
+// so that keys are matched to fields as with any map. Keys of slice fields take all their values,
+// while other keys take the first one. Other targets are left to the default map conversion
+func convertFromValues(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	if targetType.Kind() != reflect.Struct || hasBuiltinConverter(targetType) {
+		return nil, ErrNoConversionAvailable
+	}
+	values := source.(url.Values)
+	fields := ce.mappedFields(targetType)
+	m := make(map[string]interface{}, len(values))
+	for key, keyValues := range values {
+		if ce.isMultiValuedKey(fields, key) {
+			m[key] = keyValues
+		} else if len(keyValues) > 0 {
+			m[key] = keyValues[0]
+		}
+	}
+	return m, nil // the map is then converted to the struct
+}
+
+// isMultiValuedKey returns true if the given form key matches a multi-valued field, exactly or,
+// if the engine's CaseInsensitiveFields option is set, case-insensitively
+func (ce *ConverterEngine) isMultiValuedKey(fields []mappedField, key string) bool {
+	for _, field := range fields {
+		if field.key == key {
+			return isMultiValued(field.Type)
+		}
+	}
+	if ce.CaseInsensitiveFields {
+		for _, field := range fields {
+			if strings.EqualFold(field.key, key) {
+				return isMultiValued(field.Type)
+			}
+		}
+	}
+	return false
+}
+
+// convertToValues converts a struct to url.Values, such as to encode a form, formatting each field as a string.
+// Slice fields produce a value per element, while nil fields and empty fields to omit are left out.
+// Other sources are left to the default map conversion
+func convertToValues(ce *ConverterEngine, c *conversion, source interface{}, targetType reflect.Type) (interface{}, error) {
+	S := reflect.ValueOf(source)
+	if S.Kind() != reflect.Struct || hasBuiltinConverter(S.Type()) {
+		return nil, ErrNoConversionAvailable
+	}
+	stringType := reflect.TypeOf("")
+	values := make(url.Values)
+	for _, field := range ce.mappedFields(S.Type()) {
+		fieldValue, err := S.FieldByIndexErr(field.Index)
+		if err != nil {
+			continue // fields of nil embedded struct pointers are left out
+		}
+		if ce.omitsEmpty(field.StructField) && isEmptyValue(fieldValue) {
+			continue
+		}
+		for fieldValue.Kind() == reflect.Ptr && !fieldValue.IsNil() {
+			fieldValue = fieldValue.Elem()
+		}
+		if fieldValue.Kind() == reflect.Ptr || (fieldValue.Kind() == reflect.Slice && fieldValue.IsNil()) {
+			continue
+		}
+		if !isMultiValued(fieldValue.Type()) {
+			value, err := ce.convertElement(c, field.key, ce.formatField(field.StructField, fieldValue.Interface(), stringType), stringType)
+			if err != nil {
+				return nil, err
+			}
+			values.Add(field.key, value.(string))
+			continue
+		}
+		for i := 0; i < fieldValue.Len(); i++ {
+			value, err := ce.convertElement(c, field.key+indexElement(i), fieldValue.Index(i).Interface(), stringType)
+			if err != nil {
+				return nil, err
+			}
+			values.Add(field.key, value.(string))
+		}
+	}
+	return values, nil
+}
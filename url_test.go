@@ -0,0 +1,112 @@
+package elastic_test
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/epiclabs-io/elastic"
+	"github.com/epiclabs-io/ut"
+)
+
+func TestURL(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	const link = "https://user@example.com:8080/path?q=1#top"
+	expected, _ := url.Parse(link)
+
+	u, err := elastic.Convert(link, reflect.TypeOf(&url.URL{}))
+	t.Ok(err)
+	t.Equals(expected, u)
+	t.Equals(*expected, elastic.MustConvert(link, reflect.TypeOf(url.URL{})))
+
+	t.Equals(link, elastic.MustConvert(expected, reflect.TypeOf("")))
+	t.Equals(link, elastic.MustConvert(*expected, reflect.TypeOf("")))
+
+	_, err = elastic.Convert("http://[::1", reflect.TypeOf(&url.URL{}))
+	t.Assert(errors.Is(err, elastic.ErrParse), "Expected ErrParse, got %v", err)
+	var urlErr *url.Error
+	t.Assert(errors.As(err, &urlErr), "Expected the url.Error to be kept, got %v", err)
+
+	var service struct {
+		Endpoint *url.URL
+	}
+	err = elastic.Set(&service, map[string]interface{}{"Endpoint": link})
+	t.Ok(err)
+	t.Equals(expected, service.Endpoint)
+
+	var m map[string]interface{}
+	err = elastic.Set(&m, service)
+	t.Ok(err)
+	t.Equals(expected, m["Endpoint"])
+}
+
+type SearchForm struct {
+	Query    string `elastic:"q"`
+	Page     int
+	Tags     []string
+	Exact    *bool
+	PageSize int
+	internal string
+}
+
+func TestURLValues(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	exact := true
+	form := SearchForm{Query: "go", Page: 2, Tags: []string{"a", "b"}, Exact: &exact}
+	values, err := elastic.Convert(form, reflect.TypeOf(url.Values{}))
+	t.Ok(err)
+	t.Equals(url.Values{"q": {"go"}, "Page": {"2"}, "Tags": {"a", "b"}, "Exact": {"true"}, "PageSize": {"0"}}, values)
+
+	var parsed SearchForm
+	err = elastic.Set(&parsed, url.Values{"q": {"go", "ignored"}, "Page": {"3"}, "Tags": {"x"}, "Exact": {"false"}, "other": {"1"}})
+	t.Ok(err)
+	t.Equals("go", parsed.Query)
+	t.Equals(3, parsed.Page)
+	t.Equals([]string{"x"}, parsed.Tags)
+	t.Equals(false, *parsed.Exact)
+
+	engine := elastic.New()
+	engine.SetFieldNameMapper(elastic.SnakeCase)
+	values, err = engine.Convert(&SearchForm{PageSize: 50}, reflect.TypeOf(url.Values{}))
+	t.Ok(err)
+	t.Equals("50", values.(url.Values).Get("page_size"))
+
+	err = elastic.Set(&parsed, url.Values{"Page": {"first"}})
+	var conversionError *elastic.ConversionError
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("Page", conversionError.Path)
+
+	// form keys are matched to fields as map keys are
+	engine = elastic.New()
+	engine.ErrorOnUnknownFields = true
+	err = engine.Set(&parsed, url.Values{"q": {"go"}, "tiemout": {"1"}})
+	t.Assert(errors.Is(err, elastic.ErrUnknownFields), "Expected ErrUnknownFields, got %v", err)
+	t.Equals("Unknown fields: tiemout", err.Error())
+
+	engine = elastic.New()
+	engine.CaseInsensitiveFields = true
+	parsed = SearchForm{}
+	t.Ok(engine.Set(&parsed, url.Values{"Q": {"go"}, "page": {"4"}, "tags": {"x", "y"}}))
+	t.Equals(SearchForm{Query: "go", Page: 4, Tags: []string{"x", "y"}}, parsed)
+
+	// elements that fail to format report their path and honor the engine's error policy
+	type Subscription struct {
+		Topics  []string
+		Updates []chan int
+	}
+	subscription := Subscription{Topics: []string{"news"}, Updates: []chan int{nil}}
+	_, err = elastic.Convert(subscription, reflect.TypeOf(url.Values{}))
+	t.Assert(errors.As(err, &conversionError), "Expected a ConversionError")
+	t.Equals("Updates[0]", conversionError.Path)
+
+	engine = elastic.New()
+	engine.OnError = elastic.UseZero
+	values, err = engine.Convert(subscription, reflect.TypeOf(url.Values{}))
+	t.Ok(err)
+	t.Equals(url.Values{"Topics": {"news"}, "Updates": {""}}, values)
+}